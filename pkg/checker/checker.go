@@ -0,0 +1,143 @@
+// Package checker defines the interface implemented by every kind of port check, and a registry that picks
+// the checker for a port by its type field or URL scheme.
+//
+// Custom binaries can add protocols by registering their own checkers before running PongHub:
+//
+//	func main() {
+//		checker.Register("tcp", checker.CheckerFunc(checkTCP))
+//		ponghub.Main()
+//	}
+//
+// CheckServices runs the checks of a whole configuration and can be used directly by other Go programs.
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/protos/failureReason"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// Target defines a single port to be checked, with all settings needed by a checker
+type Target struct {
+	Service         string
+	Type            string
+	URL             string
+	Method          string
+	Body            string
+	StatusCodes     config.StatusCodes
+	ResponseRegex   string
+	Command         string
+	Args            []string
+	Headers         map[string]string
+	TLS             *config.TLSConfig
+	FollowRedirects bool
+	MaxRedirects    int
+	Timeout         time.Duration
+
+	// Client is the HTTP client shared by HTTP based checks
+	Client *http.Client
+
+	// Options holds the fields of the port configuration that PongHub does not know, for custom checkers
+	Options map[string]any
+}
+
+// Result defines the outcome of a single attempt to check a target.
+// Reason classifies a failure for the retry policy; failures without a reason are always retried.
+type Result struct {
+	Online     testResult.TestResult
+	Latency    time.Duration
+	StatusCode int
+	Reason     failureReason.FailureReason
+	Error      string
+	Details    string
+	Metrics    []Metric
+}
+
+// Checker checks a target once; retries are handled by the caller.
+// Check must return once ctx is done, with a CANCELLED result if the check was interrupted by it.
+type Checker interface {
+	Check(ctx context.Context, target *Target) Result
+}
+
+// CheckerFunc adapts an ordinary function to the Checker interface
+type CheckerFunc func(ctx context.Context, target *Target) Result
+
+// Check calls f(ctx, target)
+func (f CheckerFunc) Check(ctx context.Context, target *Target) Result {
+	return f(ctx, target)
+}
+
+var (
+	checkersMu sync.RWMutex
+	checkers   = map[string]Checker{}
+)
+
+func init() {
+	Register("http", httpChecker{})
+	Register("https", httpChecker{})
+	Register("exec", execChecker{})
+}
+
+// Register makes a checker available for targets with the given type or URL scheme.
+// It panics if a checker is nil or already registered under that name.
+func Register(name string, c Checker) {
+	checkersMu.Lock()
+	defer checkersMu.Unlock()
+
+	name = strings.ToLower(name)
+	if c == nil {
+		panic("checker: Register checker is nil")
+	}
+	if _, dup := checkers[name]; dup {
+		panic("checker: Register called twice for " + name)
+	}
+	checkers[name] = c
+}
+
+// Kind returns the name of the checker for the target: its type, "exec" for commands, or its URL scheme
+func (t *Target) Kind() string {
+	switch {
+	case t.Type != "":
+		return strings.ToLower(t.Type)
+	case t.Command != "":
+		return "exec"
+	}
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Scheme)
+}
+
+// String returns a short description of the target for logs
+func (t *Target) String() string {
+	switch {
+	case t.Command != "":
+		return strings.TrimSpace("EXEC " + t.Command + " " + strings.Join(t.Args, " "))
+	case t.Method != "":
+		return t.Method + " " + t.URL
+	default:
+		return t.URL
+	}
+}
+
+// Lookup returns the checker registered for the kind of the target
+func Lookup(t *Target) (Checker, error) {
+	checkersMu.RLock()
+	defer checkersMu.RUnlock()
+
+	kind := t.Kind()
+	c, ok := checkers[kind]
+	if !ok {
+		return nil, fmt.Errorf("no checker registered for %q", kind)
+	}
+	return c, nil
+}
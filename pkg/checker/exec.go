@@ -0,0 +1,243 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wcy-dt/ponghub/protos/failureReason"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// Nagios plugin exit codes
+const (
+	nagiosOK       = 0
+	nagiosWarning  = 1
+	nagiosCritical = 2
+	nagiosUnknown  = 3
+)
+
+// pluginWaitDelay is how long the output of a plugin is still read once it exited or was killed,
+// in case a child it left running keeps the output open
+const pluginWaitDelay = time.Second
+
+// Metric defines a single performance data item reported by a Nagios plugin
+type Metric struct {
+	Label string  `json:"label"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+	Warn  string  `json:"warn,omitempty"`
+	Crit  string  `json:"crit,omitempty"`
+	Min   string  `json:"min,omitempty"`
+	Max   string  `json:"max,omitempty"`
+}
+
+// getExitCodeResult converts a Nagios plugin exit code to a test result
+func getExitCodeResult(code int) testResult.TestResult {
+	switch code {
+	case nagiosOK:
+		return testResult.ALL
+	case nagiosWarning:
+		return testResult.PART
+	case nagiosCritical:
+		return testResult.NONE
+	default:
+		return testResult.UNKNOWN
+	}
+}
+
+// parsePluginOutput splits the output of a Nagios plugin into its text and performance data parts
+func parsePluginOutput(output string) (string, []Metric) {
+	lines := strings.Split(strings.TrimRight(output, "\r\n"), "\n")
+
+	// the first line is "TEXT | PERFDATA", the long text may contain more perfdata after a "|"
+	var text []string
+	var perf []string
+	inPerf := false
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if inPerf {
+			perf = append(perf, line)
+			continue
+		}
+		before, after, found := strings.Cut(line, "|")
+		text = append(text, strings.TrimSpace(before))
+		if found {
+			perf = append(perf, after)
+			if i > 0 {
+				inPerf = true
+			}
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(text, "\n")), parsePerfData(strings.Join(perf, " "))
+}
+
+// parsePerfData parses Nagios performance data in the form 'label'=value[UOM];[warn];[crit];[min];[max]
+func parsePerfData(perf string) []Metric {
+	var metrics []Metric
+	for _, item := range splitPerfData(perf) {
+		label, data, found := cutPerfLabel(item)
+		if !found || label == "" {
+			continue
+		}
+
+		fields := strings.Split(data, ";")
+		value, unit := splitPerfValue(fields[0])
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		m := Metric{Label: label, Value: v, Unit: unit}
+		for i, f := range fields[1:] {
+			switch i {
+			case 0:
+				m.Warn = f
+			case 1:
+				m.Crit = f
+			case 2:
+				m.Min = f
+			case 3:
+				m.Max = f
+			}
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// cutPerfLabel splits a performance data item around the "=" after its label, unquoting the label
+// if it is quoted, where a doubled quote stands for a quote within it
+func cutPerfLabel(item string) (string, string, bool) {
+	if !strings.HasPrefix(item, "'") {
+		return strings.Cut(item, "=")
+	}
+	for i := 1; i < len(item); i++ {
+		if item[i] != '\'' {
+			continue
+		}
+		if i+1 < len(item) && item[i+1] == '\'' {
+			i++
+			continue
+		}
+		if i+1 < len(item) && item[i+1] == '=' {
+			return strings.ReplaceAll(item[1:i], "''", "'"), item[i+2:], true
+		}
+		break
+	}
+	return "", "", false
+}
+
+// splitPerfData splits performance data into items, keeping quoted labels with spaces intact
+func splitPerfData(perf string) []string {
+	var items []string
+	var cur strings.Builder
+	quoted := false
+	for _, r := range perf {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+			cur.WriteRune(r)
+		case (r == ' ' || r == '\t') && !quoted:
+			if cur.Len() > 0 {
+				items = append(items, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		items = append(items, cur.String())
+	}
+	return items
+}
+
+// splitPerfValue splits a performance data value into its number and unit of measurement
+func splitPerfValue(s string) (string, string) {
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !strings.ContainsRune("0123456789.-+eE", r)
+	})
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
+
+// withStderr appends the error output of a plugin, if any, to the error of its check
+func withStderr(msg, stderr string) string {
+	if stderr == "" {
+		return msg
+	}
+	return fmt.Sprintf("%s, Stderr: %s", msg, stderr)
+}
+
+// execChecker checks a target by running its command as a Nagios-compatible plugin
+type execChecker struct{}
+
+// Check runs the command of the target once and maps its exit code to a test result
+func (execChecker) Check(parent context.Context, t *Target) Result {
+	ctx, cancel := context.WithTimeout(parent, t.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.Command, t.Args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = min(pluginWaitDelay, t.Timeout)
+
+	start := time.Now()
+	err := cmd.Run()
+	latency := time.Since(start)
+
+	output := stdout.String()
+	text, metrics := parsePluginOutput(output)
+	errText := strings.TrimSpace(stderr.String())
+
+	exitCode := nagiosOK
+	exited := cmd.ProcessState != nil && cmd.ProcessState.Exited()
+	switch {
+	case parent.Err() != nil:
+		return Result{
+			Online:  testResult.CANCELLED,
+			Latency: latency,
+			Error:   fmt.Sprintf("ExitCode: N/A, Error: %s", context.Cause(parent).Error()),
+		}
+	case exited:
+		// the plugin exited by itself, even if a child it left running kept its output open past the timeout
+		exitCode = cmd.ProcessState.ExitCode()
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return Result{
+			Online:  testResult.NONE,
+			Latency: latency,
+			Reason:  failureReason.TIMEOUT,
+			Error:   withStderr(fmt.Sprintf("ExitCode: N/A, Error: timed out after %s", t.Timeout), errText),
+			Details: output,
+			Metrics: metrics,
+		}
+	case err != nil:
+		return Result{
+			Online:  testResult.UNKNOWN,
+			Latency: latency,
+			Reason:  failureReason.ERROR,
+			Error:   withStderr(fmt.Sprintf("ExitCode: N/A, Error: %s", err.Error()), errText),
+		}
+	}
+
+	res := Result{
+		Online:  getExitCodeResult(exitCode),
+		Latency: latency,
+		Metrics: metrics,
+	}
+	if res.Online != testResult.ALL {
+		res.Reason = failureReason.PLUGIN
+		res.Error = withStderr(fmt.Sprintf("ExitCode: %d, Output: %s", exitCode, text), errText)
+		res.Details = output
+	}
+	return res
+}
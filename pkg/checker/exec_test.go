@@ -0,0 +1,185 @@
+package checker
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wcy-dt/ponghub/protos/failureReason"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+func TestParsePluginOutput(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		wantText    string
+		wantMetrics []Metric
+	}{
+		{name: "empty", output: "", wantText: ""},
+		{name: "text only", output: "OK - all good\n", wantText: "OK - all good"},
+		{
+			name:        "perfdata on the first line",
+			output:      "OK - fine | time=0.5s;1;2;0;10\n",
+			wantText:    "OK - fine",
+			wantMetrics: []Metric{{Label: "time", Value: 0.5, Unit: "s", Warn: "1", Crit: "2", Min: "0", Max: "10"}},
+		},
+		{
+			name: "perfdata after the long text",
+			output: "DISK OK - free space | /=2643MB;5948;5958;0;5968\n" +
+				"/ 15272 MB (77%);\n" +
+				"/boot 68 MB (69%); | /boot=68MB;88;93;0;98\n" +
+				"/home=69357MB;253404;253409;0;253414\n" +
+				"/var/log=818MB;970;975;0;980\n",
+			wantText: "DISK OK - free space\n/ 15272 MB (77%);\n/boot 68 MB (69%);",
+			wantMetrics: []Metric{
+				{Label: "/", Value: 2643, Unit: "MB", Warn: "5948", Crit: "5958", Min: "0", Max: "5968"},
+				{Label: "/boot", Value: 68, Unit: "MB", Warn: "88", Crit: "93", Min: "0", Max: "98"},
+				{Label: "/home", Value: 69357, Unit: "MB", Warn: "253404", Crit: "253409", Min: "0", Max: "253414"},
+				{Label: "/var/log", Value: 818, Unit: "MB", Warn: "970", Crit: "975", Min: "0", Max: "980"},
+			},
+		},
+		{
+			name:     "long text without perfdata",
+			output:   "WARNING - slow | rta=120ms\nfirst detail\nsecond detail\n",
+			wantText: "WARNING - slow\nfirst detail\nsecond detail",
+			wantMetrics: []Metric{
+				{Label: "rta", Value: 120, Unit: "ms"},
+			},
+		},
+		{
+			name:     "CRLF line endings",
+			output:   "OK\r\nlong text | load=0.5\r\nusers=3\r\n",
+			wantText: "OK\nlong text",
+			wantMetrics: []Metric{
+				{Label: "load", Value: 0.5},
+				{Label: "users", Value: 3},
+			},
+		},
+		{
+			name:     "quoted labels",
+			output:   "OK | 'in use'=75%;80;90 'it''s'=1c 'a=b'=2B 'end'''=3",
+			wantText: "OK",
+			wantMetrics: []Metric{
+				{Label: "in use", Value: 75, Unit: "%", Warn: "80", Crit: "90"},
+				{Label: "it's", Value: 1, Unit: "c"},
+				{Label: "a=b", Value: 2, Unit: "B"},
+				{Label: "end'", Value: 3},
+			},
+		},
+		{
+			name:     "units and thresholds",
+			output:   "OK | time=0.25s;0.5;1 usage=42.5%;80:;90:;0;100 size=1024B;;;0 requests=1500c ratio=1 temp=-1.5e2",
+			wantText: "OK",
+			wantMetrics: []Metric{
+				{Label: "time", Value: 0.25, Unit: "s", Warn: "0.5", Crit: "1"},
+				{Label: "usage", Value: 42.5, Unit: "%", Warn: "80:", Crit: "90:", Min: "0", Max: "100"},
+				{Label: "size", Value: 1024, Unit: "B", Min: "0"},
+				{Label: "requests", Value: 1500, Unit: "c"},
+				{Label: "ratio", Value: 1},
+				{Label: "temp", Value: -150},
+			},
+		},
+		{
+			name:        "malformed items",
+			output:      "OK | novalue =5 a=abc b= 'unclosed=1 ok=3;;;0",
+			wantText:    "OK",
+			wantMetrics: nil,
+		},
+		{
+			name:        "malformed items around a valid one",
+			output:      "OK | novalue =5 a=abc b= ok=3;;;0 'x'y=1",
+			wantText:    "OK",
+			wantMetrics: []Metric{{Label: "ok", Value: 3, Min: "0"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, metrics := parsePluginOutput(tt.output)
+			if text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+			if !reflect.DeepEqual(metrics, tt.wantMetrics) {
+				t.Errorf("metrics = %+v, want %+v", metrics, tt.wantMetrics)
+			}
+		})
+	}
+}
+
+func TestSplitPerfValue(t *testing.T) {
+	tests := []struct {
+		in, value, unit string
+	}{
+		{"0.5s", "0.5", "s"},
+		{"75%", "75", "%"},
+		{"1024B", "1024", "B"},
+		{"12c", "12", "c"},
+		{"3KB", "3", "KB"},
+		{"120ms", "120", "ms"},
+		{"-3", "-3", ""},
+		{"1e3", "1e3", ""},
+		{"", "", ""},
+		{"U", "", "U"},
+	}
+	for _, tt := range tests {
+		value, unit := splitPerfValue(tt.in)
+		if value != tt.value || unit != tt.unit {
+			t.Errorf("splitPerfValue(%q) = %q, %q, want %q, %q", tt.in, value, unit, tt.value, tt.unit)
+		}
+	}
+}
+
+func TestGetExitCodeResult(t *testing.T) {
+	tests := []struct {
+		code int
+		want testResult.TestResult
+	}{
+		{0, testResult.ALL},
+		{1, testResult.PART},
+		{2, testResult.NONE},
+		{3, testResult.UNKNOWN},
+		{4, testResult.UNKNOWN},
+		{127, testResult.UNKNOWN},
+		{255, testResult.UNKNOWN},
+		{-1, testResult.UNKNOWN},
+	}
+	for _, tt := range tests {
+		if got := getExitCodeResult(tt.code); got != tt.want {
+			t.Errorf("getExitCodeResult(%d) = %s, want %s", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestExecCheck(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell to run plugins:", err)
+	}
+	tests := []struct {
+		name        string
+		script      string
+		want        testResult.TestResult
+		wantReason  failureReason.FailureReason
+		wantMetrics int
+	}{
+		{name: "ok", script: "echo 'OK | time=1s'; exit 0", want: testResult.ALL, wantMetrics: 1},
+		{name: "warning", script: "echo 'WARNING | time=2s'; exit 1", want: testResult.PART, wantReason: failureReason.PLUGIN, wantMetrics: 1},
+		{name: "critical", script: "echo CRITICAL; exit 2", want: testResult.NONE, wantReason: failureReason.PLUGIN},
+		{name: "unknown", script: "echo UNKNOWN; exit 3", want: testResult.UNKNOWN, wantReason: failureReason.PLUGIN},
+		{name: "other exit code", script: "exit 42", want: testResult.UNKNOWN, wantReason: failureReason.PLUGIN},
+		{name: "timeout", script: "sleep 5", want: testResult.NONE, wantReason: failureReason.TIMEOUT},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &Target{Command: "sh", Args: []string{"-c", tt.script}, Timeout: 500 * time.Millisecond}
+			res := execChecker{}.Check(context.Background(), target)
+			if res.Online != tt.want || res.Reason != tt.wantReason {
+				t.Errorf("Check() = %s (%s), want %s (%s): %s", res.Online, res.Reason, tt.want, tt.wantReason, res.Error)
+			}
+			if len(res.Metrics) != tt.wantMetrics {
+				t.Errorf("Check() metrics = %+v, want %d", res.Metrics, tt.wantMetrics)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/wcy-dt/ponghub/protos/failureReason"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// httpChecker checks a target by sending an HTTP request to its URL
+type httpChecker struct{}
+
+// getHttpMethod converts a string method to an HTTP method constant
+func getHttpMethod(method string) (string, error) {
+	switch strings.ToUpper(method) {
+	case "GET":
+		return http.MethodGet, nil
+	case "POST":
+		return http.MethodPost, nil
+	case "PUT":
+		return http.MethodPut, nil
+	case "DELETE", "HEAD", "PATCH", "OPTIONS", "TRACE", "CONNECT":
+		return "", fmt.Errorf("method %s not supported", strings.ToUpper(method))
+	default:
+		return http.MethodGet, nil // Default to GET if method is unknown
+	}
+}
+
+// isSuccessfulResponse checks if the response from the server is successful based on the target
+func isSuccessfulResponse(t *Target, resp *http.Response, body []byte) (bool, error) {
+	// responseRegex is set, and the response body does not match the regex
+	if t.ResponseRegex != "" {
+		matched, err := regexp.Match(t.ResponseRegex, body)
+		if err != nil {
+			return false, fmt.Errorf("error parsing regexp: %w", err)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	// statusCode and responseRegex are not set, and the response is OK
+	if len(t.StatusCodes) == 0 && t.ResponseRegex == "" && resp.StatusCode == http.StatusOK {
+		return true, nil
+	}
+
+	// statusCode is not set, and the responseRegex matches
+	if len(t.StatusCodes) == 0 && t.ResponseRegex != "" {
+		return true, nil
+	}
+
+	// statusCode is set, and the response matches one of the expected status codes
+	if len(t.StatusCodes) != 0 && t.StatusCodes.Contains(resp.StatusCode) {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// getRequestFailureReason classifies an error returned while sending a request or reading its response
+func getRequestFailureReason(err error) failureReason.FailureReason {
+	var netErr net.Error
+	if errors.Is(err, errTooManyRedirects) {
+		// a redirect loop or chain longer than max_redirects does not go away by retrying
+		return failureReason.MISMATCH
+	}
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return failureReason.TIMEOUT
+	}
+	return failureReason.CONNECTION
+}
+
+// interrupted reports whether the request failed with err because the parent context of the check is done
+func interrupted(parent context.Context, err error) bool {
+	return parent.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded))
+}
+
+// getResponseFailureReason classifies an unexpected response by its status code
+func getResponseFailureReason(statusCode int) failureReason.FailureReason {
+	switch {
+	case statusCode >= 500:
+		return failureReason.SERVER_ERROR
+	case statusCode >= 400:
+		return failureReason.CLIENT_ERROR
+	default:
+		return failureReason.MISMATCH
+	}
+}
+
+// Check sends a single request to the target and validates the response
+func (httpChecker) Check(parent context.Context, t *Target) Result {
+	ctx, cancel := context.WithTimeout(parent, t.Timeout)
+	defer cancel()
+
+	// build the request
+	client, err := httpClientFor(t)
+	if err != nil {
+		return Result{
+			Online: testResult.NONE,
+			Reason: failureReason.ERROR,
+			Error:  fmt.Sprintf("StatusCode: N/A, Error: %s", err.Error()),
+		}
+	}
+	method, err := getHttpMethod(t.Method)
+	if err != nil {
+		return Result{
+			Online: testResult.NONE,
+			Reason: failureReason.ERROR,
+			Error:  fmt.Sprintf("StatusCode: N/A, Error: %s", err.Error()),
+		}
+	}
+	var body io.Reader
+	if t.Body != "" {
+		body = strings.NewReader(t.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, t.URL, body)
+	if err != nil {
+		return Result{
+			Online: testResult.NONE,
+			Reason: failureReason.ERROR,
+			Error:  fmt.Sprintf("StatusCode: N/A, Error: %s", err.Error()),
+		}
+	}
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+
+	// get the response
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		if interrupted(parent, err) {
+			return Result{
+				Online:  testResult.CANCELLED,
+				Latency: time.Since(start),
+				Error:   fmt.Sprintf("StatusCode: N/A, Error: %s", context.Cause(parent).Error()),
+			}
+		}
+		return Result{
+			Online:  testResult.NONE,
+			Latency: time.Since(start),
+			Reason:  getRequestFailureReason(err),
+			Error:   fmt.Sprintf("StatusCode: N/A, Error: %s", err.Error()),
+		}
+	}
+	defer func(resp *http.Response) {
+		_ = resp.Body.Close()
+	}(resp)
+	respBody, err := io.ReadAll(resp.Body)
+	latency := time.Since(start)
+	if err != nil {
+		if interrupted(parent, err) {
+			return Result{
+				Online:     testResult.CANCELLED,
+				Latency:    latency,
+				StatusCode: resp.StatusCode,
+				Error:      fmt.Sprintf("StatusCode: %d, Error: %s", resp.StatusCode, context.Cause(parent).Error()),
+			}
+		}
+		return Result{
+			Online:     testResult.NONE,
+			Latency:    latency,
+			StatusCode: resp.StatusCode,
+			Reason:     getRequestFailureReason(err),
+			Error:      fmt.Sprintf("StatusCode: %d, Error: %s", resp.StatusCode, err.Error()),
+		}
+	}
+
+	// check the response
+	ok, err := isSuccessfulResponse(t, resp, respBody)
+	switch {
+	case err != nil:
+		return Result{
+			Online:     testResult.NONE,
+			Latency:    latency,
+			StatusCode: resp.StatusCode,
+			Reason:     failureReason.ERROR,
+			Error:      fmt.Sprintf("StatusCode: %d, Error: %s", resp.StatusCode, err.Error()),
+		}
+	case ok:
+		return Result{
+			Online:     testResult.ALL,
+			Latency:    latency,
+			StatusCode: resp.StatusCode,
+		}
+	}
+	return Result{
+		Online:     testResult.NONE,
+		Latency:    latency,
+		StatusCode: resp.StatusCode,
+		Reason:     getResponseFailureReason(resp.StatusCode),
+		Error:      fmt.Sprintf("StatusCode or ResponseRegex mismatch: %d", resp.StatusCode),
+		Details:    string(respBody),
+	}
+}
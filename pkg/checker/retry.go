@@ -0,0 +1,52 @@
+package checker
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/protos/failureReason"
+)
+
+// backoff returns the delay before the given retry, growing exponentially from the initial interval
+// with a random jitter, up to the max interval
+func backoff(policy *config.RetryPolicy, retry int) time.Duration {
+	if policy == nil || policy.InitialInterval <= 0 {
+		return 0
+	}
+	delay := float64(policy.InitialInterval) * math.Pow(policy.Multiplier, float64(retry-1))
+	if policy.Jitter != nil && *policy.Jitter > 0 {
+		delay += delay * *policy.Jitter * (2*rand.Float64() - 1)
+	}
+	// the jitter never takes the delay past the max interval
+	if policy.MaxInterval > 0 && delay > float64(policy.MaxInterval) {
+		delay = float64(policy.MaxInterval)
+	}
+	return time.Duration(delay)
+}
+
+// shouldRetry reports whether a failure with the given reason is retried by the policy
+func shouldRetry(policy *config.RetryPolicy, reason failureReason.FailureReason) bool {
+	if policy == nil || reason == "" {
+		return true
+	}
+	return slices.Contains(policy.RetryOn, reason.String())
+}
+
+// sleep waits for the delay, and reports false if ctx is done first
+func sleep(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
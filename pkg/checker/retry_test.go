@@ -0,0 +1,167 @@
+package checker
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/protos/failureReason"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// the test checker returns the results listed in the "results" option of the port, one per attempt,
+// the last one being repeated
+func init() {
+	Register("test", CheckerFunc(func(ctx context.Context, target *Target) Result {
+		calls := target.Options["calls"].(*int)
+		results := target.Options["results"].([]Result)
+		res := results[min(*calls, len(results)-1)]
+		*calls++
+		return res
+	}))
+}
+
+func TestBackoff(t *testing.T) {
+	jitter := func(f float64) *float64 { return &f }
+	tests := []struct {
+		name     string
+		policy   *config.RetryPolicy
+		retry    int
+		min, max time.Duration
+	}{
+		{name: "no policy", retry: 1},
+		{name: "no initial interval", policy: &config.RetryPolicy{Multiplier: 2}, retry: 3},
+		{name: "first retry", policy: &config.RetryPolicy{InitialInterval: time.Second, Multiplier: 2}, retry: 1,
+			min: time.Second, max: time.Second},
+		{name: "second retry", policy: &config.RetryPolicy{InitialInterval: time.Second, Multiplier: 2}, retry: 2,
+			min: 2 * time.Second, max: 2 * time.Second},
+		{name: "fourth retry", policy: &config.RetryPolicy{InitialInterval: time.Second, Multiplier: 3}, retry: 4,
+			min: 27 * time.Second, max: 27 * time.Second},
+		{name: "constant", policy: &config.RetryPolicy{InitialInterval: time.Second, Multiplier: 1}, retry: 5,
+			min: time.Second, max: time.Second},
+		{name: "max interval", policy: &config.RetryPolicy{InitialInterval: time.Second, MaxInterval: 5 * time.Second, Multiplier: 2}, retry: 4,
+			min: 5 * time.Second, max: 5 * time.Second},
+		{name: "below the max interval", policy: &config.RetryPolicy{InitialInterval: time.Second, MaxInterval: 5 * time.Second, Multiplier: 2}, retry: 3,
+			min: 4 * time.Second, max: 4 * time.Second},
+		{name: "zero jitter", policy: &config.RetryPolicy{InitialInterval: time.Second, Multiplier: 2, Jitter: jitter(0)}, retry: 2,
+			min: 2 * time.Second, max: 2 * time.Second},
+		{name: "jitter", policy: &config.RetryPolicy{InitialInterval: time.Second, Multiplier: 2, Jitter: jitter(0.5)}, retry: 2,
+			min: time.Second, max: 3 * time.Second},
+		{name: "jitter clamped to the max interval", policy: &config.RetryPolicy{InitialInterval: time.Second, MaxInterval: 2500 * time.Millisecond, Multiplier: 2, Jitter: jitter(0.5)}, retry: 2,
+			min: time.Second, max: 2500 * time.Millisecond},
+		{name: "jitter past the max interval", policy: &config.RetryPolicy{InitialInterval: time.Second, MaxInterval: 3 * time.Second, Multiplier: 2, Jitter: jitter(0.2)}, retry: 3,
+			min: 3 * time.Second, max: 3 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the jitter is random, so every delay is checked against the bounds
+			for range 100 {
+				if got := backoff(tt.policy, tt.retry); got < tt.min || got > tt.max {
+					t.Fatalf("backoff(%d) = %s, want between %s and %s", tt.retry, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	policy := &config.RetryPolicy{RetryOn: []string{"connection", "timeout", "5xx"}}
+	tests := []struct {
+		policy *config.RetryPolicy
+		reason failureReason.FailureReason
+		want   bool
+	}{
+		{policy, failureReason.CONNECTION, true},
+		{policy, failureReason.TIMEOUT, true},
+		{policy, failureReason.SERVER_ERROR, true},
+		{policy, failureReason.CLIENT_ERROR, false},
+		{policy, failureReason.MISMATCH, false},
+		{policy, failureReason.PLUGIN, false},
+		{policy, "", true},
+		{nil, failureReason.CLIENT_ERROR, true},
+	}
+	for _, tt := range tests {
+		if got := shouldRetry(tt.policy, tt.reason); got != tt.want {
+			t.Errorf("shouldRetry(%v, %q) = %v, want %v", tt.policy != nil, tt.reason, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPortRetry(t *testing.T) {
+	ok := Result{Online: testResult.ALL}
+	serverError := Result{Online: testResult.NONE, StatusCode: 503, Reason: failureReason.SERVER_ERROR, Error: "HTTP 503"}
+	clientError := Result{Online: testResult.NONE, StatusCode: 404, Reason: failureReason.CLIENT_ERROR, Error: "HTTP 404"}
+	mismatch := Result{Online: testResult.NONE, StatusCode: 200, Reason: failureReason.MISMATCH, Error: "response does not match"}
+	noReason := Result{Online: testResult.NONE, Error: "failed"}
+
+	policy := func(maxElapsed time.Duration) *config.RetryPolicy {
+		jitter := 0.0
+		return &config.RetryPolicy{
+			InitialInterval: 20 * time.Millisecond,
+			Multiplier:      2,
+			Jitter:          &jitter,
+			MaxElapsed:      maxElapsed,
+			RetryOn:         []string{"connection", "timeout", "5xx"},
+		}
+	}
+	tests := []struct {
+		name       string
+		retry      int
+		policy     *config.RetryPolicy
+		results    []Result
+		want       testResult.TestResult
+		wantDelays []int64 // the delay before every attempt, in milliseconds
+	}{
+		{name: "success", retry: 3, policy: policy(0), results: []Result{ok},
+			want: testResult.ALL, wantDelays: []int64{0}},
+		{name: "success after retries", retry: 3, policy: policy(0), results: []Result{serverError, serverError, ok},
+			want: testResult.PART, wantDelays: []int64{0, 20, 40}},
+		{name: "5xx retried", retry: 4, policy: policy(0), results: []Result{serverError},
+			want: testResult.NONE, wantDelays: []int64{0, 20, 40, 80}},
+		{name: "4xx not retried", retry: 3, policy: policy(0), results: []Result{clientError},
+			want: testResult.NONE, wantDelays: []int64{0}},
+		{name: "mismatch not retried", retry: 3, policy: policy(0), results: []Result{mismatch},
+			want: testResult.NONE, wantDelays: []int64{0}},
+		{name: "4xx after a 5xx", retry: 3, policy: policy(0), results: []Result{serverError, clientError, ok},
+			want: testResult.NONE, wantDelays: []int64{0, 20}},
+		{name: "failure without a reason", retry: 2, policy: policy(0), results: []Result{noReason},
+			want: testResult.NONE, wantDelays: []int64{0, 20}},
+		{name: "max elapsed", retry: 5, policy: policy(50 * time.Millisecond), results: []Result{serverError},
+			want: testResult.NONE, wantDelays: []int64{0, 20}},
+		{name: "no retry", retry: 1, policy: policy(0), results: []Result{serverError, ok},
+			want: testResult.NONE, wantDelays: []int64{0}},
+	}
+	opts := &Options{Logger: log.New(io.Discard, "", 0)}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			port := &config.PortConfig{
+				URL: "test://port",
+				CheckSettings: config.CheckSettings{
+					Retry:       &tt.retry,
+					RetryPolicy: tt.policy,
+				},
+				Options: map[string]any{"calls": &calls, "results": tt.results},
+			}
+			pr := CheckPort(context.Background(), &config.ServiceConfig{Name: "Service"}, port, opts)
+			if pr.Online != tt.want {
+				t.Errorf("CheckPort() = %s, want %s: %q", pr.Online, tt.want, pr.Failures)
+			}
+			if calls != len(tt.wantDelays) || pr.TotalAttempts != calls || len(pr.Attempts) != calls {
+				t.Fatalf("CheckPort() made %d calls, %d attempts recorded as %+v, want %d",
+					calls, pr.TotalAttempts, pr.Attempts, len(tt.wantDelays))
+			}
+			for i, a := range pr.Attempts {
+				if a.DelayMs != tt.wantDelays[i] {
+					t.Errorf("attempt %d delay = %dms, want %dms", i, a.DelayMs, tt.wantDelays[i])
+				}
+				if want := tt.results[min(i, len(tt.results)-1)]; a.Online != want.Online || a.Reason != want.Reason {
+					t.Errorf("attempt %d = %+v, want %+v", i, a, want)
+				}
+			}
+		})
+	}
+}
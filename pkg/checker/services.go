@@ -0,0 +1,362 @@
+package checker
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/protos/failureReason"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// CheckResult defines the structure for the result of checking a service
+type CheckResult struct {
+	Name          string                `json:"name"`
+	Online        testResult.TestResult `json:"online"`
+	Health        []PortResult          `json:"health,omitempty"`
+	API           []PortResult          `json:"api,omitempty"`
+	Maintenance   string                `json:"maintenance,omitempty"` // name of the maintenance window the service was checked in
+	StartTime     string                `json:"start_time"`
+	EndTime       string                `json:"end_time"`
+	TotalAttempts int                   `json:"total_attempts"`
+	SuccessCount  int                   `json:"success_count"`
+}
+
+// PortResult defines the structure for the result of checking a port
+type PortResult struct {
+	ID            string                `json:"id"`
+	Name          string                `json:"name"`
+	URL           string                `json:"url,omitempty"`
+	Command       string                `json:"command,omitempty"`
+	Method        string                `json:"method"`
+	Body          string                `json:"body,omitempty"`
+	Online        testResult.TestResult `json:"online"`
+	StatusCode    int                   `json:"status_code,omitempty"`
+	LatencyMs     int64                 `json:"latency_ms,omitempty"`
+	StartTime     string                `json:"start_time"`
+	EndTime       string                `json:"end_time"`
+	TotalAttempts int                   `json:"total_attempts"`
+	SuccessCount  int                   `json:"success_count"`
+	Failures      []string              `json:"failures,omitempty"`
+	ResponseBody  string                `json:"response_body,omitempty"`
+	Metrics       []Metric              `json:"metrics,omitempty"`
+	Attempts      []Attempt             `json:"attempts,omitempty"`
+}
+
+// Attempt defines the outcome of a single attempt to check a port, and the delay waited before it
+type Attempt struct {
+	Time       string                      `json:"time"`
+	DelayMs    int64                       `json:"delay_ms,omitempty"`
+	Online     testResult.TestResult       `json:"online"`
+	StatusCode int                         `json:"status_code,omitempty"`
+	LatencyMs  int64                       `json:"latency_ms,omitempty"`
+	Reason     failureReason.FailureReason `json:"reason,omitempty"`
+	Error      string                      `json:"error,omitempty"`
+}
+
+// Endpoint returns what the port checks, the URL for HTTP ports or the command line for exec ports
+func (pr PortResult) Endpoint() string {
+	if pr.Command != "" {
+		return pr.Command
+	}
+	return pr.URL
+}
+
+// Redact applies redact to every text of the port result that may contain a configuration value
+func (pr *PortResult) Redact(redact func(string) string) {
+	pr.ID = redact(pr.ID)
+	pr.Name = redact(pr.Name)
+	pr.URL = redact(pr.URL)
+	pr.Command = redact(pr.Command)
+	pr.Body = redact(pr.Body)
+	pr.ResponseBody = redact(pr.ResponseBody)
+	for i := range pr.Failures {
+		pr.Failures[i] = redact(pr.Failures[i])
+	}
+	for i := range pr.Attempts {
+		pr.Attempts[i].Error = redact(pr.Attempts[i].Error)
+	}
+	for i := range pr.Metrics {
+		pr.Metrics[i].Label = redact(pr.Metrics[i].Label)
+	}
+}
+
+// Redact applies redact to the results of every port of the service
+func (r *CheckResult) Redact(redact func(string) string) {
+	for i := range r.Health {
+		r.Health[i].Redact(redact)
+	}
+	for i := range r.API {
+		r.API[i].Redact(redact)
+	}
+}
+
+// Options defines the dependencies used while checking services
+type Options struct {
+	// Client is the HTTP client used by HTTP checks, http.DefaultClient if nil
+	Client *http.Client
+
+	// Logger receives the progress of the checks, log.Default() if nil
+	Logger *log.Logger
+}
+
+// logger returns the logger of the options, or the default logger
+func (o *Options) logger() *log.Logger {
+	if o == nil || o.Logger == nil {
+		return log.Default()
+	}
+	return o.Logger
+}
+
+// client returns the HTTP client of the options, or the default client
+func (o *Options) client() *http.Client {
+	if o == nil || o.Client == nil {
+		return http.DefaultClient
+	}
+	return o.Client
+}
+
+// getTestResult determines the test result based on the success count and actual attempts
+func getTestResult(successCount, actualAttempts int) testResult.TestResult {
+	switch successCount {
+	case actualAttempts:
+		return testResult.ALL
+	case 0:
+		return testResult.NONE
+	default:
+		return testResult.PART
+	}
+}
+
+// newTarget builds the checker target for a port of a service
+func newTarget(cfg *config.PortConfig, svcName string, opts *Options) *Target {
+	target := &Target{
+		Service:         svcName,
+		Type:            cfg.Type,
+		URL:             cfg.URL,
+		Method:          strings.ToUpper(cfg.Method),
+		Body:            cfg.Body,
+		StatusCodes:     cfg.StatusCode,
+		ResponseRegex:   cfg.ResponseRegex,
+		Command:         cfg.Command,
+		Args:            cfg.Args,
+		Headers:         cfg.Headers,
+		TLS:             cfg.TLS,
+		FollowRedirects: cfg.FollowRedirects == nil || *cfg.FollowRedirects,
+		MaxRedirects:    cfg.MaxRedirects,
+		Timeout:         time.Duration(cfg.Timeout) * time.Second,
+		Client:          opts.client(),
+		Options:         cfg.Options,
+	}
+	if kind := target.Kind(); target.Method == "" && (kind == "http" || kind == "https") {
+		target.Method = http.MethodGet
+	}
+	return target
+}
+
+// CheckPort checks a single port of a service with its resolved settings, retrying failures according
+// to its retry policy, and gives up when ctx is done
+func CheckPort(ctx context.Context, svc *config.ServiceConfig, cfg *config.PortConfig, opts *Options) PortResult {
+	logger := opts.logger()
+	failures := []string{}
+	attempts := []Attempt{}
+	successCount := 0
+	actualAttempts := 0
+	retryTimes := 1
+	if cfg.Retry != nil {
+		retryTimes = max(*cfg.Retry, 1)
+	}
+
+	target := newTarget(cfg, svc.Name, opts)
+	var last Result
+
+	// start timer
+	start := time.Now()
+
+	c, err := Lookup(target)
+	if err != nil {
+		last = Result{Online: testResult.UNKNOWN, Error: err.Error()}
+		failures = append(failures, err.Error())
+		logger.Printf("[%s] %s FAILED - %s", svc.Name, target, err.Error())
+		retryTimes = 0
+	}
+
+	for attemptTimes := range retryTimes {
+		// wait before retrying, unless the next attempt would exceed the max elapsed time
+		var delay time.Duration
+		if attemptTimes > 0 {
+			delay = backoff(cfg.RetryPolicy, attemptTimes)
+			if p := cfg.RetryPolicy; p != nil && p.MaxElapsed > 0 && time.Since(start)+delay > p.MaxElapsed {
+				logger.Printf("[%s] %s not retried - max elapsed time %s reached", svc.Name, target, p.MaxElapsed)
+				break
+			}
+		}
+		if !sleep(ctx, delay) {
+			break
+		}
+		actualAttempts++
+		logger.Printf("[%s] %s (attempt %d/%d)\n",
+			svc.Name, target, attemptTimes+1, retryTimes)
+
+		attemptStart := time.Now()
+		last = c.Check(ctx, target)
+		attempts = append(attempts, Attempt{
+			Time:       attemptStart.Format(time.RFC3339),
+			DelayMs:    delay.Milliseconds(),
+			Online:     last.Online,
+			StatusCode: last.StatusCode,
+			LatencyMs:  last.Latency.Milliseconds(),
+			Reason:     last.Reason,
+			Error:      last.Error,
+		})
+		if last.Online == testResult.ALL {
+			successCount++
+			break
+		}
+		if last.Online == testResult.CANCELLED {
+			break
+		}
+		failures = append(failures, last.Error)
+		logger.Printf("FAILED - %s", last.Error)
+		if attemptTimes+1 < retryTimes && !shouldRetry(cfg.RetryPolicy, last.Reason) {
+			logger.Printf("[%s] %s not retried - %s failures are not retried", svc.Name, target, last.Reason)
+			break
+		}
+	}
+
+	// end timer
+	end := time.Now()
+
+	// a port that only succeeds after failed attempts is partially online,
+	// otherwise the last attempt decides, which lets plugins report warnings
+	online := last.Online
+	responseBody := last.Details
+	if successCount > 0 {
+		online = getTestResult(successCount, actualAttempts)
+		responseBody = ""
+	} else if last.Online == testResult.CANCELLED || (c != nil && actualAttempts == 0 && ctx.Err() != nil) {
+		// the run was interrupted before the port failed, so it is neither up nor down
+		online = testResult.CANCELLED
+		failures = append(failures, "Cancelled: "+context.Cause(ctx).Error())
+		logger.Printf("[%s] %s CANCELLED - %s", svc.Name, target, context.Cause(ctx).Error())
+	}
+
+	return PortResult{
+		ID:            cfg.Identity(),
+		Name:          cfg.DisplayName(),
+		URL:           cfg.URL,
+		Command:       strings.TrimSpace(cfg.Command + " " + strings.Join(cfg.Args, " ")),
+		Method:        target.Method,
+		Body:          cfg.Body,
+		Online:        online,
+		StatusCode:    last.StatusCode,
+		LatencyMs:     last.Latency.Milliseconds(),
+		StartTime:     start.Format(time.RFC3339),
+		EndTime:       end.Format(time.RFC3339),
+		TotalAttempts: actualAttempts,
+		SuccessCount:  successCount,
+		Failures:      failures,
+		ResponseBody:  responseBody,
+		Metrics:       last.Metrics,
+		Attempts:      attempts,
+	}
+}
+
+// getServiceResult determines the test result of a service from its ports,
+// a service with any cancelled port is cancelled as its status would be incomplete
+func getServiceResult(onlinePorts, cancelledPorts, totalPorts int) testResult.TestResult {
+	if cancelledPorts > 0 {
+		return testResult.CANCELLED
+	}
+	return getTestResult(onlinePorts, totalPorts)
+}
+
+// CheckServices checks all services defined in the configuration.
+// If ctx is done before all services are checked, the remaining ports are marked as cancelled
+// and the results are returned with the context error.
+func CheckServices(ctx context.Context, cfg *config.Config, opts *Options) ([]CheckResult, error) {
+	results := []CheckResult{}
+	for _, svc := range cfg.Services {
+
+		// start timer
+		svcStart := time.Now()
+
+		totalAttempts := 0
+		successCount := 0
+		totalPorts := 0
+		onlinePorts := 0
+		cancelledPorts := 0
+
+		// check health ports
+		healthResults := []PortResult{}
+		for _, h := range svc.Health {
+			pr := CheckPort(ctx, &svc, &h, opts)
+			healthResults = append(healthResults, pr)
+			totalAttempts += pr.TotalAttempts
+			successCount += pr.SuccessCount
+			totalPorts++
+			switch pr.Online {
+			case testResult.ALL:
+				onlinePorts++
+			case testResult.CANCELLED:
+				cancelledPorts++
+			}
+		}
+
+		// check API ports
+		apiResults := []PortResult{}
+		for _, a := range svc.API {
+			pr := CheckPort(ctx, &svc, &a, opts)
+			apiResults = append(apiResults, pr)
+			totalAttempts += pr.TotalAttempts
+			successCount += pr.SuccessCount
+			totalPorts++
+			switch pr.Online {
+			case testResult.ALL:
+				onlinePorts++
+			case testResult.CANCELLED:
+				cancelledPorts++
+			}
+		}
+
+		// end timer
+		svcEnd := time.Now()
+
+		online := getServiceResult(onlinePorts, cancelledPorts, totalPorts)
+
+		// checks during a maintenance window are recorded as maintenance, whatever their outcome
+		var maintenance string
+		if window := cfg.InMaintenance(&svc, svcStart); window != nil && online != testResult.CANCELLED {
+			maintenance = window.Name
+			if maintenance == "" {
+				maintenance = "maintenance"
+			}
+			online = testResult.MAINTENANCE
+			for _, ports := range [][]PortResult{healthResults, apiResults} {
+				for i := range ports {
+					if ports[i].Online != testResult.CANCELLED {
+						ports[i].Online = testResult.MAINTENANCE
+					}
+				}
+			}
+			opts.logger().Printf("[%s] in %s, recorded as maintenance", svc.Name, maintenance)
+		}
+
+		res := CheckResult{
+			Name:          svc.Name,
+			Online:        online,
+			Maintenance:   maintenance,
+			Health:        healthResults,
+			API:           apiResults,
+			StartTime:     svcStart.Format(time.RFC3339),
+			EndTime:       svcEnd.Format(time.RFC3339),
+			TotalAttempts: totalAttempts,
+			SuccessCount:  successCount,
+		}
+		results = append(results, res)
+	}
+	return results, context.Cause(ctx)
+}
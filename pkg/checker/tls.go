@@ -0,0 +1,110 @@
+package checker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+)
+
+// tlsVersions maps the TLS versions accepted in the configuration to their constants
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsClients caches the HTTP clients built for TLS settings, so ports sharing settings share connections
+var tlsClients sync.Map
+
+// newTLSClientConfig converts the TLS settings of a port to a TLS client configuration
+func newTLSClientConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		ServerName: cfg.ServerName,
+		MinVersion: tlsVersions[cfg.MinVersion],
+	}
+	if cfg.InsecureSkipVerify != nil {
+		tlsCfg.InsecureSkipVerify = *cfg.InsecureSkipVerify
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	return tlsCfg, nil
+}
+
+// defaultMaxRedirects is the number of redirects followed when max_redirects is not set, as by net/http
+const defaultMaxRedirects = 10
+
+// errTooManyRedirects is returned by a request stopped after the maximum number of redirects
+var errTooManyRedirects = errors.New("too many redirects")
+
+// httpClientFor returns the HTTP client of the target, using its TLS and redirect settings
+func httpClientFor(t *Target) (*http.Client, error) {
+	client, err := tlsClientFor(t)
+	if err != nil {
+		return nil, err
+	}
+
+	maxRedirects := t.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	c := *client
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !t.FollowRedirects {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w: stopped after %d redirects", errTooManyRedirects, maxRedirects)
+		}
+		return nil
+	}
+	return &c, nil
+}
+
+// tlsClientFor returns the HTTP client of the target, with a transport using its TLS settings if it has any
+func tlsClientFor(t *Target) (*http.Client, error) {
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if t.TLS == nil || *t.TLS == (config.TLSConfig{}) {
+		return client, nil
+	}
+
+	insecure := t.TLS.InsecureSkipVerify != nil && *t.TLS.InsecureSkipVerify
+	key := fmt.Sprintf("%p|%t|%s|%s|%s", client, insecure, t.TLS.ServerName, t.TLS.CAFile, t.TLS.MinVersion)
+	if c, ok := tlsClients.Load(key); ok {
+		return c.(*http.Client), nil
+	}
+
+	tlsCfg, err := newTLSClientConfig(t.TLS)
+	if err != nil {
+		return nil, err
+	}
+	base, ok := client.Transport.(*http.Transport)
+	if !ok {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	transport := base.Clone()
+	transport.TLSClientConfig = tlsCfg
+
+	c := *client
+	c.Transport = transport
+	actual, _ := tlsClients.LoadOrStore(key, &c)
+	return actual.(*http.Client), nil
+}
@@ -0,0 +1,362 @@
+// Package config loads and validates the PongHub configuration file.
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/wcy-dt/ponghub/protos/defaultConfig"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ServiceConfig defines the configuration for a service, including its health and API ports.
+// Services sharing a group are shown together in the report, and tags select services for a run.
+type ServiceConfig struct {
+	Name   string       `yaml:"name"`
+	Source string       `yaml:"source,omitempty"` // file the service is defined in, set when loading
+	Group  string       `yaml:"group,omitempty"`
+	Tags   []string     `yaml:"tags,omitempty"`
+	Health []PortConfig `yaml:"health,omitempty"`
+	API    []PortConfig `yaml:"api,omitempty"`
+
+	// PreviousNames lists the former names of the service, whose history is kept under the current name
+	PreviousNames []string `yaml:"previous_names,omitempty"`
+
+	// Maintenance lists the maintenance windows of the service, in addition to the global ones
+	Maintenance []MaintenanceWindow `yaml:"maintenance,omitempty"`
+
+	// SLO is the availability objective of the service, whose error budget is shown in the report
+	SLO *SLOConfig `yaml:"slo,omitempty"`
+
+	// CheckSettings override the global settings for the ports of this service
+	CheckSettings `yaml:",inline"`
+}
+
+// PortConfig defines the configuration for a port, checked by the checker registered for Type,
+// for the URL scheme, or for "exec" when Command is set. The history of a port is kept under its
+// identity, and Name is shown in the report instead of the URL, which HideURL leaves out entirely.
+type PortConfig struct {
+	ID            string   `yaml:"id,omitempty"`
+	Name          string   `yaml:"name,omitempty"`
+	Description   string   `yaml:"description,omitempty"`
+	HideURL       bool     `yaml:"hide_url,omitempty"`
+	Type          string   `yaml:"type,omitempty"`
+	URL           string   `yaml:"url,omitempty"`
+	Method        string   `yaml:"method,omitempty"`
+	Body          string   `yaml:"body,omitempty"`
+	ResponseRegex string   `yaml:"response_regex,omitempty"`
+	Command       string   `yaml:"command,omitempty"`
+	Args          []string `yaml:"args,omitempty"`
+
+	// CheckSettings override the settings of the service for this port
+	CheckSettings `yaml:",inline"`
+
+	// Options holds any other fields, passed to custom checkers and rejected for the built-in ones
+	Options map[string]any `yaml:",inline"`
+}
+
+// Config defines the overall configuration structure for the application
+type Config struct {
+	Services   []ServiceConfig `yaml:"services"`
+	MaxLogDays int             `yaml:"max_log_days,omitempty"`
+	Retention  Retention       `yaml:"retention,omitempty"`
+	RunTimeout int             `yaml:"run_timeout,omitempty"`
+	Report     ReportConfig    `yaml:"report,omitempty"`
+	Reports    []ReportTarget  `yaml:"reports,omitempty"`
+
+	// Maintenance lists the maintenance windows of all services
+	Maintenance []MaintenanceWindow `yaml:"maintenance,omitempty"`
+
+	// Incidents is the directory of the incident files, relative to the configuration file
+	Incidents string `yaml:"incidents,omitempty"`
+
+	// IncidentThreshold is the number of consecutive failed checks of a service that open an incident
+	IncidentThreshold int `yaml:"incident_threshold,omitempty"`
+
+	// CheckSettings are the global settings, inherited by all services
+	CheckSettings `yaml:",inline"`
+
+	// Secrets holds the values interpolated from secret files and environment variables marked as secret
+	Secrets []string `yaml:"-"`
+}
+
+// SetDefaultFields resolves the check settings of every port, from the built-in defaults
+// through the global and service settings, and sets the other default values
+func SetDefaultFields(cfg *Config) {
+	defaultConfig.SetDefaultMaxLogDays(&cfg.MaxLogDays)
+	cfg.Retention.setDefaults(cfg.MaxLogDays)
+	defaultConfig.SetDefaultIncidentThreshold(&cfg.IncidentThreshold)
+	cfg.Report.inherit(&ReportConfig{
+		Sort:         SortConfig,
+		Retired:      RetiredHide,
+		PartCountsAs: PartDown,
+		UnknownAfter: defaultConfig.GetDefaultUnknownAfter(),
+		Timeline: Timeline{
+			Span:   Period(defaultConfig.GetDefaultTimelineSpan()),
+			Bucket: Period(defaultConfig.GetDefaultTimelineBucket()),
+			Color:  ColorWorst,
+		},
+	})
+	for i := range cfg.Reports {
+		cfg.Reports[i].setDefaults(&cfg.Report)
+	}
+
+	cfg.CheckSettings.inherit(defaultSettings())
+	for i := range cfg.Services {
+		svc := &cfg.Services[i]
+		if svc.SLO != nil {
+			defaultConfig.SetDefaultSLOWindowDays(&svc.SLO.WindowDays)
+		}
+		svc.CheckSettings.inherit(&cfg.CheckSettings)
+		for j := range svc.Health {
+			svc.Health[j].CheckSettings.inherit(&svc.CheckSettings)
+		}
+		for j := range svc.API {
+			svc.API[j].CheckSettings.inherit(&svc.CheckSettings)
+		}
+	}
+}
+
+// Endpoint returns what the port checks, the command line for exec ports or the URL otherwise
+func (p *PortConfig) Endpoint() string {
+	if p.Command != "" {
+		return strings.TrimSpace(p.Command + " " + strings.Join(p.Args, " "))
+	}
+	return p.URL
+}
+
+// describe returns what the port checks, prefixed with the method unless it is GET
+func (p *PortConfig) describe() string {
+	desc := p.Endpoint()
+	if desc == "" {
+		desc = p.Type
+	}
+	if method := strings.ToUpper(p.Method); p.Command == "" && method != "" && method != http.MethodGet {
+		desc = method + " " + desc
+	}
+	return desc
+}
+
+// Identity returns the key the history of the port is kept under: ID if set, otherwise what the port checks,
+// prefixed with the method unless it is GET and followed by a hash of the body if it has one
+func (p *PortConfig) Identity() string {
+	if p.ID != "" {
+		return p.ID
+	}
+	id := p.describe()
+	if p.Body != "" {
+		sum := sha256.Sum256([]byte(p.Body))
+		id += " #" + hex.EncodeToString(sum[:4])
+	}
+	return id
+}
+
+// DisplayName returns the name of the port shown in the report: Name if set,
+// otherwise what the port checks, prefixed with the method unless it is GET
+func (p *PortConfig) DisplayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.describe()
+}
+
+// builtinKinds lists the checkers built into PongHub, which take no fields beyond the ones of PortConfig
+var builtinKinds = []string{"http", "https", "exec"}
+
+// kind returns the name of the checker of the port: its type, "exec" for commands, or its URL scheme
+func (p *PortConfig) kind() string {
+	switch {
+	case p.Type != "":
+		return strings.ToLower(p.Type)
+	case p.Command != "":
+		return "exec"
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Scheme)
+}
+
+// validate checks that the port can be checked
+func (p *PortConfig) validate() error {
+	if p.URL == "" && p.Command == "" && p.Type == "" {
+		return errors.New("neither url, command nor type is set")
+	}
+	if p.HideURL && p.Name == "" {
+		return errors.New("hide_url requires a name to show instead")
+	}
+	// the fields left in Options are only read by custom checkers, so for the built-in ones they are misspelled
+	if kind := p.kind(); len(p.Options) > 0 && slices.Contains(builtinKinds, kind) {
+		fields := make([]string, 0, len(p.Options))
+		for field := range p.Options {
+			fields = append(fields, field)
+		}
+		slices.Sort(fields)
+		return fmt.Errorf("unknown fields for the %s checker: %s", kind, strings.Join(fields, ", "))
+	}
+	return p.CheckSettings.validate()
+}
+
+// Validate checks that the configuration defines at least one service and that every port can be checked
+func (cfg *Config) Validate() error {
+	if len(cfg.Services) == 0 {
+		return errors.New("no services defined in the configuration file")
+	}
+	if err := cfg.CheckSettings.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Report.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Retention.validate(cfg.MaxLogDays); err != nil {
+		return err
+	}
+	for i := range cfg.Maintenance {
+		if err := cfg.Maintenance[i].validate(); err != nil {
+			return fmt.Errorf("maintenance %d: %w", i+1, err)
+		}
+	}
+	outputs := map[string]bool{}
+	for i := range cfg.Reports {
+		r := &cfg.Reports[i]
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("report %d: %w", i+1, err)
+		}
+		if outputs[r.Output] {
+			return fmt.Errorf("report %d: output %s is used by another report", i+1, r.Output)
+		}
+		outputs[r.Output] = true
+	}
+	definedIn := map[string]string{}
+	previousOf := map[string]string{}
+	for _, svc := range cfg.Services {
+		if svc.Name == "" {
+			return withSource(svc.Source, errors.New("service without a name"))
+		}
+		if prev, ok := definedIn[svc.Name]; ok {
+			return fmt.Errorf("service %q is defined more than once, in %s and %s", svc.Name, describeSource(prev), describeSource(svc.Source))
+		}
+		definedIn[svc.Name] = svc.Source
+		for _, prev := range svc.PreviousNames {
+			if prevOf, ok := previousOf[prev]; ok {
+				return fmt.Errorf("previous name %q is used by both service %q and service %q", prev, prevOf, svc.Name)
+			}
+			previousOf[prev] = svc.Name
+		}
+
+		if err := svc.CheckSettings.validate(); err != nil {
+			return withSource(svc.Source, fmt.Errorf("service %q: %w", svc.Name, err))
+		}
+		if svc.SLO != nil {
+			if err := svc.SLO.validate(); err != nil {
+				return withSource(svc.Source, fmt.Errorf("service %q: %w", svc.Name, err))
+			}
+		}
+		for i := range svc.Maintenance {
+			if err := svc.Maintenance[i].validate(); err != nil {
+				return withSource(svc.Source, fmt.Errorf("service %q: maintenance %d: %w", svc.Name, i+1, err))
+			}
+		}
+		// the history of the ports is kept under their identity, derived or set with id
+		ids := map[string]bool{}
+		for _, p := range slices.Concat(svc.Health, svc.API) {
+			id := p.Identity()
+			if ids[id] {
+				if p.ID != "" {
+					return withSource(svc.Source, fmt.Errorf("service %q: port id %q is also the identity of another port; set a distinct id", svc.Name, id))
+				}
+				return withSource(svc.Source, fmt.Errorf("service %q: more than one port has the identity %q; set a distinct id on them", svc.Name, id))
+			}
+			ids[id] = true
+		}
+		for i, p := range svc.Health {
+			if err := p.validate(); err != nil {
+				return withSource(svc.Source, fmt.Errorf("service %q: health port %d: %w", svc.Name, i+1, err))
+			}
+		}
+		for i, p := range svc.API {
+			if err := p.validate(); err != nil {
+				return withSource(svc.Source, fmt.Errorf("service %q: api port %d: %w", svc.Name, i+1, err))
+			}
+		}
+	}
+	for prev, name := range previousOf {
+		if _, ok := definedIn[prev]; ok {
+			return fmt.Errorf("previous name %q of service %q is the name of another service", prev, name)
+		}
+	}
+	return nil
+}
+
+// describeSource names the file a service is defined in for error messages
+func describeSource(source string) string {
+	if source == "" {
+		return "the configuration"
+	}
+	return source
+}
+
+// Decode reads a YAML configuration from r, sets its default values and validates it.
+// Secret files and includes referenced with relative paths are looked up in the working directory.
+func Decode(r io.Reader) (*Config, error) {
+	l := newLoader()
+	if err := l.decode(r, ".", ""); err != nil {
+		return nil, err
+	}
+	return l.config(".")
+}
+
+// Encode writes the configuration to w as YAML, with its secrets redacted
+func Encode(w io.Writer, cfg *Config) error {
+	// the secrets are redacted in the values rather than in the output, where YAML may quote, escape or fold them
+	var root yaml.Node
+	if err := root.Encode(cfg); err != nil {
+		return err
+	}
+	redactNode(&root, cfg.Redact)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// redactNode applies redact to the scalars of the node tree, turning the ones it changes into strings
+func redactNode(node *yaml.Node, redact func(string) string) {
+	if node.Kind == yaml.ScalarNode {
+		if value := redact(node.Value); value != node.Value {
+			node.Value, node.Tag, node.Style = value, "!!str", 0
+		}
+		return
+	}
+	for _, child := range node.Content {
+		redactNode(child, redact)
+	}
+}
+
+// Load loads the configuration from a YAML file at the specified path, together with the files it includes.
+// If path is a directory, all YAML files in it are loaded in name order.
+func Load(path string) (*Config, error) {
+	l := newLoader()
+	if err := l.loadPath(path); err != nil {
+		return nil, err
+	}
+	dir := path
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	return l.config(dir)
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDecodePortFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		port    string
+		wantErr string
+	}{
+		{name: "http", port: "url: https://example.com\ntimeout: 5\nstatus_code: 204\nfollow_redirects: false"},
+		{name: "exec", port: "command: check_load\nargs: [-w, 1]\ntimeout: 5"},
+		{name: "misspelled timeout", port: "url: https://example.com\ntimout: 5", wantErr: "unknown fields for the https checker: timout"},
+		{name: "misspelled status code", port: "url: http://example.com\nstatus_codes: 204", wantErr: "unknown fields for the http checker: status_codes"},
+		{name: "several misspelled", port: "url: https://example.com\nfolow_redirects: false\nmethdo: POST", wantErr: "folow_redirects, methdo"},
+		{name: "misspelled on exec", port: "command: check_load\nargz: [-w, 1]", wantErr: "unknown fields for the exec checker: argz"},
+		{name: "http type", port: "type: HTTP\nurl: https://example.com\ntimout: 5", wantErr: "unknown fields for the http checker: timout"},
+		{name: "custom scheme", port: "url: tcp://example.com:22\nbanner: SSH"},
+		{name: "custom type", port: "type: dns\nurl: https://example.com\nrecord: A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port := "    - " + strings.ReplaceAll(tt.port, "\n", "\n      ")
+			_, err := Decode(strings.NewReader("services:\n  - name: Service\n    health:\n" + port + "\n"))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Decode() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncodeRedacted(t *testing.T) {
+	dir := t.TempDir()
+	secrets := map[string]string{
+		"quoted.txt":    `p@ss: "word" #1`,
+		"multiline.txt": "first line\nsecond line",
+		"spaced.txt":    "  leading spaces",
+		"escaped.txt":   "tab\there\\back",
+	}
+	for name, content := range secrets {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in := `services:
+  - name: Service
+    health:
+      - url: https://example.com/${file:spaced.txt}
+        body: ${file:multiline.txt}
+        headers:
+          Authorization: Bearer ${file:quoted.txt}
+          X-Token: ${file:escaped.txt}
+`
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(in), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := Encode(&b, cfg); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, part := range []string{"p@ss", "word", "first line", "second line", "leading spaces", "here", "back"} {
+		if strings.Contains(out, part) {
+			t.Errorf("Encode() leaks %q:\n%s", part, out)
+		}
+	}
+
+	var got Config
+	if err := yaml.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("Encode() wrote invalid YAML: %v\n%s", err, out)
+	}
+	port := got.Services[0].Health[0]
+	want := map[string]string{"Authorization": "Bearer ******", "X-Token": "******"}
+	if port.URL != "https://example.com/******" || port.Body != "******" || !maps.Equal(port.Headers, want) {
+		t.Errorf("Encode() = %+v, want the secrets redacted", port)
+	}
+}
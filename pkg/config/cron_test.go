@@ -0,0 +1,161 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+// bitsOf returns the bit set of the values
+func bitsOf(values ...int) uint64 {
+	var bits uint64
+	for _, v := range values {
+		bits |= 1 << v
+	}
+	return bits
+}
+
+// rangeOf returns the values from lo to hi
+func rangeOf(lo, hi int) []int {
+	var values []int
+	for v := lo; v <= hi; v++ {
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		want     uint64
+		wantErr  bool
+	}{
+		{field: "*", min: 0, max: 59, want: bitsOf(rangeOf(0, 59)...)},
+		{field: "*/15", min: 0, max: 59, want: bitsOf(0, 15, 30, 45)},
+		{field: "*/2", min: 1, max: 12, want: bitsOf(1, 3, 5, 7, 9, 11)},
+		{field: "1-5", min: 0, max: 7, want: bitsOf(1, 2, 3, 4, 5)},
+		{field: "0,30", min: 0, max: 59, want: bitsOf(0, 30)},
+		{field: "1-10/3", min: 0, max: 59, want: bitsOf(1, 4, 7, 10)},
+		{field: "5/20", min: 0, max: 59, want: bitsOf(5, 25, 45)},
+		{field: "0-4,20-22", min: 0, max: 23, want: bitsOf(0, 1, 2, 3, 4, 20, 21, 22)},
+		{field: "59", min: 0, max: 59, want: bitsOf(59)},
+		{field: "*/0", min: 0, max: 59, wantErr: true},
+		{field: "*/-5", min: 0, max: 59, wantErr: true},
+		{field: "*/x", min: 0, max: 59, wantErr: true},
+		{field: "60", min: 0, max: 59, wantErr: true},
+		{field: "0", min: 1, max: 31, wantErr: true},
+		{field: "5-1", min: 0, max: 59, wantErr: true},
+		{field: "1-60", min: 0, max: 59, wantErr: true},
+		{field: "-1", min: 0, max: 59, wantErr: true},
+		{field: "a", min: 0, max: 59, wantErr: true},
+		{field: "", min: 0, max: 59, wantErr: true},
+		{field: "1,", min: 0, max: 59, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCronField(tt.field, tt.min, tt.max)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCronField(%q, %d, %d) error = %v, wantErr %v", tt.field, tt.min, tt.max, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseCronField(%q, %d, %d) = %b, want %b", tt.field, tt.min, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: "0 3 * * 0"},
+		{expr: "*/5 9-17 * * 1-5"},
+		{expr: "0 0 29 2 *"},
+		{expr: "0 0 * * 7"},
+		{expr: "0 3 * *", wantErr: true},
+		{expr: "0 3 * * * *", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "0 24 * * *", wantErr: true},
+		{expr: "0 0 32 * *", wantErr: true},
+		{expr: "0 0 0 * *", wantErr: true},
+		{expr: "0 0 * 13 *", wantErr: true},
+		{expr: "0 0 * * 8", wantErr: true},
+	}
+	for _, tt := range tests {
+		if _, err := parseCron(tt.expr); (err != nil) != tt.wantErr {
+			t.Errorf("parseCron(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+		}
+	}
+
+	// Sunday is both 0 and 7
+	sunday, err := parseCron("0 0 * * 7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sunday.dow != bitsOf(0, 7) {
+		t.Errorf("day of week of 7 = %b, want %b", sunday.dow, bitsOf(0, 7))
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available:", err)
+	}
+	tests := []struct {
+		name string
+		expr string
+		loc  *time.Location
+		from string
+		want string // empty if the schedule never matches
+	}{
+		{name: "step", expr: "*/15 * * * *", loc: time.UTC, from: "2026-10-19T10:07:30Z", want: "2026-10-19T10:15:00Z"},
+		{name: "strictly after", expr: "0 * * * *", loc: time.UTC, from: "2026-10-19T10:00:00Z", want: "2026-10-19T11:00:00Z"},
+		{name: "across midnight", expr: "30 0 * * *", loc: time.UTC, from: "2026-10-19T23:50:00Z", want: "2026-10-20T00:30:00Z"},
+		{name: "across the year", expr: "0 0 1 1 *", loc: time.UTC, from: "2026-06-01T00:00:00Z", want: "2027-01-01T00:00:00Z"},
+		{name: "range of hours", expr: "0 9-17 * * *", loc: time.UTC, from: "2026-10-19T17:30:00Z", want: "2026-10-20T09:00:00Z"},
+		{name: "day of month", expr: "0 0 13 * *", loc: time.UTC, from: "2026-10-19T00:00:00Z", want: "2026-11-13T00:00:00Z"},
+		{name: "day of week", expr: "0 3 * * 0", loc: time.UTC, from: "2026-10-19T00:00:00Z", want: "2026-10-25T03:00:00Z"},
+		{name: "Sunday as 7", expr: "0 3 * * 7", loc: time.UTC, from: "2026-10-19T00:00:00Z", want: "2026-10-25T03:00:00Z"},
+		{name: "day of month or day of week", expr: "0 0 13 * 5", loc: time.UTC, from: "2026-10-19T00:00:00Z", want: "2026-10-23T00:00:00Z"},
+		{name: "day of week or day of month", expr: "0 0 20 * 0", loc: time.UTC, from: "2026-10-19T00:00:00Z", want: "2026-10-20T00:00:00Z"},
+		{name: "day of month with any day of week", expr: "0 0 31 * *", loc: time.UTC, from: "2026-10-31T00:00:00Z", want: "2026-12-31T00:00:00Z"},
+		{name: "leap day", expr: "0 0 29 2 *", loc: time.UTC, from: "2026-03-01T00:00:00Z", want: "2028-02-29T00:00:00Z"},
+		{name: "never", expr: "0 0 30 2 *", loc: time.UTC, from: "2026-01-01T00:00:00Z"},
+		{name: "in the location", expr: "0 3 * * *", loc: berlin, from: "2026-10-19T00:00:00Z", want: "2026-10-19T03:00:00+02:00"},
+		{name: "skipped when the clocks go forward", expr: "30 2 * * *", loc: berlin, from: "2026-03-29T00:00:00+01:00", want: "2026-03-30T02:30:00+02:00"},
+		{name: "hours when the clocks go forward", expr: "0 * * * *", loc: berlin, from: "2026-03-29T01:00:00+01:00", want: "2026-03-29T03:00:00+02:00"},
+		{name: "before the clocks go back", expr: "30 2 * * *", loc: berlin, from: "2026-10-25T02:10:00+02:00", want: "2026-10-25T02:30:00+02:00"},
+		{name: "once when the clocks go back", expr: "30 2 * * *", loc: berlin, from: "2026-10-25T02:30:00+02:00", want: "2026-10-26T02:30:00+01:00"},
+		{name: "steps once when the clocks go back", expr: "*/20 2 * * *", loc: berlin, from: "2026-10-25T02:40:00+02:00", want: "2026-10-26T02:00:00+01:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			from, err := time.Parse(time.RFC3339, tt.from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := c.next(from.In(tt.loc))
+			if tt.want == "" {
+				if !got.IsZero() {
+					t.Errorf("next(%s) = %s, want none", tt.from, got)
+				}
+				return
+			}
+			want, err := time.Parse(time.RFC3339, tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(want) {
+				t.Errorf("next(%s) = %s, want %s", tt.from, got, want)
+			}
+			if got.Location() != tt.loc {
+				t.Errorf("next(%s) is in %s, want %s", tt.from, got.Location(), tt.loc)
+			}
+		})
+	}
+}
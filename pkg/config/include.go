@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/wcy-dt/ponghub/protos/defaultConfig"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loader reads configuration files and merges them into a single configuration.
+// Services of all files are concatenated in the order they are read, while every other
+// top-level field may be set by a single file only.
+type loader struct {
+	visited  map[string]string // absolute path of every file read, to the path it was read as
+	setIn    map[string]string // top-level field, to the file that set it
+	top      yaml.Node         // merged top-level fields other than services and include
+	services []ServiceConfig
+	secrets  []string
+}
+
+// newLoader returns an empty loader
+func newLoader() *loader {
+	return &loader{
+		visited: map[string]string{},
+		setIn:   map[string]string{},
+		top:     yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"},
+	}
+}
+
+// withSource prefixes err with the file it was found in, if any
+func withSource(source string, err error) error {
+	if source == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", source, err)
+}
+
+// loadPath reads a configuration file, or all YAML files of a directory in name order
+func (l *loader) loadPath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return l.loadFile(path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		if err := l.loadFile(filepath.Join(path, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadFile reads a single configuration file
+func (l *loader) loadFile(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if prev, ok := l.visited[abs]; ok {
+		return fmt.Errorf("%s is included more than once (already read as %s)", path, prev)
+	}
+	l.visited[abs] = path
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+	return l.decode(f, filepath.Dir(path), path)
+}
+
+// decode reads a YAML configuration from r, interpolating it and resolving its includes in dir.
+// source names the file in errors and in the services it defines.
+func (l *loader) decode(r io.Reader, dir, source string) error {
+	var root yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&root); err != nil {
+		if errors.Is(err, io.EOF) {
+			// an empty file defines nothing
+			return nil
+		}
+		return withSource(source, fmt.Errorf("failed to decode YAML config: %w", err))
+	}
+	secrets, err := interpolateConfig(&root, dir)
+	if err != nil {
+		return withSource(source, err)
+	}
+	for _, s := range secrets {
+		if !slices.Contains(l.secrets, s) {
+			l.secrets = append(l.secrets, s)
+		}
+	}
+
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return withSource(source, fmt.Errorf("line %d: the configuration must be a mapping", doc.Line))
+	}
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		switch key.Value {
+		case "include":
+			var patterns []string
+			if err := value.Decode(&patterns); err != nil {
+				return withSource(source, fmt.Errorf("line %d: include must be a list of paths: %w", value.Line, err))
+			}
+			if err := l.include(patterns, dir, source); err != nil {
+				return err
+			}
+		case "services":
+			var services []ServiceConfig
+			if err := value.Decode(&services); err != nil {
+				return withSource(source, fmt.Errorf("failed to decode services: %w", err))
+			}
+			for i := range services {
+				services[i].Source = source
+			}
+			l.services = append(l.services, services...)
+		default:
+			if prev, ok := l.setIn[key.Value]; ok {
+				return withSource(source, fmt.Errorf("line %d: %s is already set in %s", key.Line, key.Value, prev))
+			}
+			l.setIn[key.Value] = source
+			l.top.Content = append(l.top.Content, key, value)
+		}
+	}
+	return nil
+}
+
+// include reads the files, directories and globs listed in patterns, relative to dir.
+// A glob that matches nothing is allowed, a missing file or directory is not.
+func (l *loader) include(patterns []string, dir, source string) error {
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		paths := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				return withSource(source, fmt.Errorf("invalid include pattern %q: %w", pattern, err))
+			}
+			paths = matches
+		}
+		for _, path := range paths {
+			if err := l.loadPath(path); err != nil {
+				return withSource(source, err)
+			}
+		}
+	}
+	return nil
+}
+
+// config builds the merged configuration, sets its default values and validates it.
+// Paths in the configuration are resolved relative to dir.
+func (l *loader) config(dir string) (*Config, error) {
+	cfg := new(Config)
+	if err := l.top.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode YAML config: %w", err)
+	}
+	if cfg.Incidents == "" {
+		cfg.Incidents = defaultConfig.GetIncidentsDir()
+	}
+	if !filepath.IsAbs(cfg.Incidents) {
+		cfg.Incidents = filepath.Join(dir, cfg.Incidents)
+	}
+	cfg.Services = l.services
+	// redact longer secrets first, in case one contains another
+	slices.SortFunc(l.secrets, func(a, b string) int { return len(b) - len(a) })
+	cfg.Secrets = l.secrets
+
+	// Set default values for the configuration
+	SetDefaultFields(cfg)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
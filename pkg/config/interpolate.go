@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// minSecretLength is the length below which secret values are rejected: they could not be redacted,
+// as replacing very short values everywhere would make logs unreadable
+const minSecretLength = 4
+
+// redactedValue replaces secret values in logs, the log file and the report
+const redactedValue = "******"
+
+// interpolator replaces references to environment variables and secret files in configuration values,
+// and remembers the secret values it inserted so they can be redacted
+type interpolator struct {
+	dir     string
+	secrets []string
+	errs    []error
+}
+
+// interpolateNode interpolates every scalar of the YAML tree in place
+func (in *interpolator) interpolateNode(n *yaml.Node) {
+	if n.Kind == yaml.ScalarNode {
+		value, changed := in.interpolate(n.Value, n.Line)
+		if changed {
+			n.Value = value
+			// let plain scalars such as timeout: ${TIMEOUT} be resolved again as numbers or booleans
+			if n.Style == 0 {
+				n.Tag = ""
+			}
+		}
+	}
+	for _, c := range n.Content {
+		in.interpolateNode(c)
+	}
+}
+
+// interpolate replaces ${VAR}, ${VAR:-default}, ${secret:VAR} and ${file:path} in s; $${ is kept as a literal ${
+func (in *interpolator) interpolate(s string, line int) (string, bool) {
+	if !strings.Contains(s, "${") {
+		return s, false
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			break
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			in.errs = append(in.errs, fmt.Errorf("line %d: unterminated reference in %q", line, s))
+			b.WriteString(s)
+			break
+		}
+		b.WriteString(s[:i])
+		b.WriteString(in.resolve(s[i+2:i+end], line))
+		s = s[i+end+1:]
+	}
+	return b.String(), true
+}
+
+// resolve returns the value of a single reference, recording the values of secret files and of
+// environment variables marked as secret as secrets
+func (in *interpolator) resolve(ref string, line int) string {
+	if path, ok := strings.CutPrefix(ref, "file:"); ok {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(in.dir, path)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			in.errs = append(in.errs, fmt.Errorf("line %d: secret file %s cannot be read: %w", line, path, err))
+			return ""
+		}
+		return in.secret(strings.TrimRight(string(b), "\r\n"), "secret file "+path, line)
+	}
+
+	if name, ok := strings.CutPrefix(ref, "secret:"); ok {
+		if !isEnvName(name) {
+			in.errs = append(in.errs, fmt.Errorf("line %d: invalid environment variable name %q", line, name))
+			return ""
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			in.errs = append(in.errs, fmt.Errorf("line %d: environment variable %s is not set", line, name))
+			return ""
+		}
+		return in.secret(value, "secret environment variable "+name, line)
+	}
+
+	name, def, hasDefault := strings.Cut(ref, ":-")
+	if !isEnvName(name) {
+		in.errs = append(in.errs, fmt.Errorf("line %d: invalid environment variable name %q", line, name))
+		return ""
+	}
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		return value
+	}
+	if !hasDefault {
+		in.errs = append(in.errs, fmt.Errorf("line %d: environment variable %s is not set", line, name))
+		return ""
+	}
+	return def
+}
+
+// secret records an interpolated value taken from source so that it can be redacted,
+// and rejects the values too short to be
+func (in *interpolator) secret(value, source string, line int) string {
+	if len(value) < minSecretLength {
+		in.errs = append(in.errs, fmt.Errorf("line %d: %s has a value of fewer than %d characters, too short to be redacted",
+			line, source, minSecretLength))
+		return ""
+	}
+	if !slices.Contains(in.secrets, value) {
+		in.secrets = append(in.secrets, value)
+	}
+	return value
+}
+
+// isEnvName reports whether s is a valid environment variable name
+func isEnvName(s string) bool {
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// interpolateConfig interpolates the YAML tree of a configuration file in dir and returns the inserted secrets
+func interpolateConfig(root *yaml.Node, dir string) ([]string, error) {
+	in := &interpolator{dir: dir}
+	in.interpolateNode(root)
+	if len(in.errs) > 0 {
+		return nil, errors.Join(in.errs...)
+	}
+	return in.secrets, nil
+}
+
+// Redact replaces the values interpolated from secret files and environment variables marked as secret in s
+func (cfg *Config) Redact(s string) string {
+	for _, secret := range cfg.Secrets {
+		s = strings.ReplaceAll(s, secret, redactedValue)
+	}
+	return s
+}
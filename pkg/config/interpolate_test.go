@@ -0,0 +1,148 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// interpolateValue interpolates the YAML value in dir and returns it decoded as a string with the secrets
+func interpolateValue(t *testing.T, value, dir string) (string, []string, error) {
+	t.Helper()
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte("value: "+value), &root); err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := interpolateConfig(&root, dir)
+	if err != nil {
+		return "", nil, err
+	}
+	var out struct{ Value string }
+	if err := root.Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	return out.Value, secrets, nil
+}
+
+func TestInterpolate(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"token.txt": "file-secret\n",
+		"crlf.txt":  "windows-secret\r\n",
+		"short.txt": "abc\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PH_TEST_TOKEN", "s3cret")
+	t.Setenv("PH_TEST_OTHER", "another")
+	t.Setenv("PH_TEST_EMPTY", "")
+	t.Setenv("PH_TEST_SHORT", "ab")
+
+	tests := []struct {
+		name        string
+		value       string
+		want        string
+		wantSecrets []string
+		wantErr     bool
+	}{
+		{name: "plain", value: "no references", want: "no references"},
+		{name: "variable", value: "${PH_TEST_TOKEN}", want: "s3cret"},
+		{name: "within text", value: "Bearer ${PH_TEST_TOKEN}", want: "Bearer s3cret"},
+		{name: "several", value: "${PH_TEST_TOKEN}:${PH_TEST_OTHER}", want: "s3cret:another"},
+		{name: "set variable with default", value: "${PH_TEST_TOKEN:-fallback}", want: "s3cret"},
+		{name: "short variable", value: "${PH_TEST_SHORT}", want: "ab"},
+		{name: "secret variable", value: "Bearer ${secret:PH_TEST_TOKEN}", want: "Bearer s3cret", wantSecrets: []string{"s3cret"}},
+		{name: "several secrets", value: "${secret:PH_TEST_TOKEN}:${secret:PH_TEST_OTHER}", want: "s3cret:another", wantSecrets: []string{"s3cret", "another"}},
+		{name: "repeated secret", value: "${secret:PH_TEST_TOKEN}${secret:PH_TEST_TOKEN}", want: "s3crets3cret", wantSecrets: []string{"s3cret"}},
+		{name: "default", value: "${PH_TEST_UNSET:-fallback}", want: "fallback"},
+		{name: "default of empty variable", value: "${PH_TEST_EMPTY:-fallback}", want: "fallback"},
+		{name: "empty default", value: "a${PH_TEST_UNSET:-}b", want: "ab"},
+		{name: "default with a dash", value: "${PH_TEST_UNSET:-a-b}", want: "a-b"},
+		{name: "escaped", value: "$${PH_TEST_TOKEN}", want: "${PH_TEST_TOKEN}"},
+		{name: "file", value: "${file:token.txt}", want: "file-secret", wantSecrets: []string{"file-secret"}},
+		{name: "file with CRLF", value: "${file:crlf.txt}", want: "windows-secret", wantSecrets: []string{"windows-secret"}},
+		{name: "absolute file", value: "${file:" + filepath.Join(dir, "token.txt") + "}", want: "file-secret", wantSecrets: []string{"file-secret"}},
+		{name: "unset variable", value: "${PH_TEST_UNSET}", wantErr: true},
+		{name: "empty variable", value: "${PH_TEST_EMPTY}", wantErr: true},
+		{name: "short secret variable", value: "${secret:PH_TEST_SHORT}", wantErr: true},
+		{name: "unset secret variable", value: "${secret:PH_TEST_UNSET}", wantErr: true},
+		{name: "empty secret variable", value: "${secret:PH_TEST_EMPTY}", wantErr: true},
+		{name: "invalid secret name", value: "${secret:1TOKEN}", wantErr: true},
+		{name: "short file", value: "${file:short.txt}", wantErr: true},
+		{name: "missing file", value: "${file:missing.txt}", wantErr: true},
+		{name: "invalid name", value: "${1TOKEN}", wantErr: true},
+		{name: "empty name", value: "${:-fallback}", wantErr: true},
+		{name: "unterminated", value: "${PH_TEST_TOKEN", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, secrets, err := interpolateValue(t, tt.value, dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("interpolate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("interpolate(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+			if !slices.Equal(secrets, tt.wantSecrets) {
+				t.Errorf("interpolate(%q) secrets = %q, want %q", tt.value, secrets, tt.wantSecrets)
+			}
+		})
+	}
+}
+
+func TestInterpolateTypes(t *testing.T) {
+	t.Setenv("PH_TEST_TIMEOUT", "30")
+	t.Setenv("PH_TEST_ENABLED", "true")
+
+	var root yaml.Node
+	in := "timeout: ${PH_TEST_TIMEOUT}\nenabled: ${PH_TEST_ENABLED}\nquoted: \"${PH_TEST_TIMEOUT}\"\n"
+	if err := yaml.Unmarshal([]byte(in), &root); err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := interpolateConfig(&root, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) > 0 {
+		t.Errorf("plain variables are recorded as secrets: %q", secrets)
+	}
+	var out struct {
+		Timeout int
+		Enabled bool
+		Quoted  string
+	}
+	if err := root.Decode(&out); err != nil {
+		t.Fatalf("plain scalars are not resolved again: %v", err)
+	}
+	if out.Timeout != 30 || !out.Enabled || out.Quoted != "30" {
+		t.Errorf("decoded %+v, want timeout 30, enabled and quoted \"30\"", out)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets []string
+		in      string
+		want    string
+	}{
+		{name: "none", in: "token s3cret", want: "token s3cret"},
+		{name: "secret", secrets: []string{"s3cret"}, in: "token s3cret", want: "token ******"},
+		{name: "every occurrence", secrets: []string{"s3cret"}, in: "s3cret/s3cret", want: "******/******"},
+		{name: "nested secrets", secrets: []string{"s3cret-long", "s3cret"}, in: "s3cret-long s3cret", want: "****** ******"},
+	}
+	for _, tt := range tests {
+		cfg := &Config{Secrets: tt.secrets}
+		if got := cfg.Redact(tt.in); got != tt.want {
+			t.Errorf("%s: Redact(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
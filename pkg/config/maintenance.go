@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// MaintenanceWindow defines a period in which the checks of a service are recorded as maintenance
+// and left out of its availability. A window is either one-off, from Start to End, or recurring,
+// starting at the times matched by the cron expression Cron and lasting Duration.
+type MaintenanceWindow struct {
+	Name     string        `yaml:"name,omitempty"`
+	Start    time.Time     `yaml:"start,omitempty"`
+	End      time.Time     `yaml:"end,omitempty"`
+	Cron     string        `yaml:"cron,omitempty"`
+	Duration time.Duration `yaml:"duration,omitempty"`
+	Timezone string        `yaml:"timezone,omitempty"` // location of the cron expression, UTC by default
+}
+
+// validate checks that the window is either one-off or recurring
+func (w *MaintenanceWindow) validate() error {
+	switch {
+	case w.Cron != "" && (!w.Start.IsZero() || !w.End.IsZero()):
+		return errors.New("maintenance window must set either start and end or cron, not both")
+	case w.Cron != "":
+		if _, err := parseCron(w.Cron); err != nil {
+			return err
+		}
+		if w.Duration <= 0 {
+			return errors.New("recurring maintenance window must set a duration")
+		}
+		if _, err := time.LoadLocation(w.Timezone); err != nil {
+			return fmt.Errorf("invalid maintenance timezone %q: %w", w.Timezone, err)
+		}
+	case w.Start.IsZero() || w.End.IsZero():
+		return errors.New("maintenance window must set start and end, or cron and duration")
+	case !w.End.After(w.Start):
+		return errors.New("maintenance window must end after it starts")
+	}
+	return nil
+}
+
+// Occurrence returns the occurrence of the window in progress at t, or else the next one after t.
+// ok is false if the window has no occurrence left.
+func (w *MaintenanceWindow) Occurrence(t time.Time) (start, end time.Time, ok bool) {
+	if w.Cron == "" {
+		return w.Start, w.End, w.End.After(t)
+	}
+
+	schedule, err := parseCron(w.Cron)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	// the first start after t - Duration is either in progress at t or the next one
+	start = schedule.next(t.In(loc).Add(-w.Duration))
+	if start.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, start.Add(w.Duration), true
+}
+
+// Active reports whether t falls within an occurrence of the window
+func (w *MaintenanceWindow) Active(t time.Time) bool {
+	start, end, ok := w.Occurrence(t)
+	return ok && !start.After(t) && end.After(t)
+}
+
+// MaintenanceOf returns the maintenance windows of the service, the global ones first
+func (cfg *Config) MaintenanceOf(svc *ServiceConfig) []MaintenanceWindow {
+	return slices.Concat(cfg.Maintenance, svc.Maintenance)
+}
+
+// InMaintenance returns the maintenance window of the service in progress at t, or nil
+func (cfg *Config) InMaintenance(svc *ServiceConfig, t time.Time) *MaintenanceWindow {
+	windows := cfg.MaintenanceOf(svc)
+	for i := range windows {
+		if windows[i].Active(t) {
+			return &windows[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowActive(t *testing.T) {
+	nightly := MaintenanceWindow{Cron: "0 23 * * *", Duration: 3 * time.Hour}
+	weekly := MaintenanceWindow{Cron: "0 22 * * 0", Duration: 4 * time.Hour}
+	berlin := MaintenanceWindow{Cron: "0 1 * * *", Duration: 2 * time.Hour, Timezone: "Europe/Berlin"}
+	oneOff := MaintenanceWindow{
+		Start: time.Date(2026, 11, 2, 22, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 11, 3, 1, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name   string
+		window MaintenanceWindow
+		at     string
+		want   bool
+	}{
+		{name: "before a window crossing midnight", window: nightly, at: "2026-10-19T22:59:59Z", want: false},
+		{name: "at the start", window: nightly, at: "2026-10-19T23:00:00Z", want: true},
+		{name: "after midnight", window: nightly, at: "2026-10-20T01:30:00Z", want: true},
+		{name: "at the end", window: nightly, at: "2026-10-20T02:00:00Z", want: false},
+		{name: "into the next day of the week", window: weekly, at: "2026-10-26T01:59:00Z", want: true},
+		{name: "on the next day of the week", window: weekly, at: "2026-10-26T22:30:00Z", want: false},
+		{name: "in the time zone", window: berlin, at: "2026-10-19T23:30:00Z", want: true},
+		{name: "outside of the time zone", window: berlin, at: "2026-10-19T01:30:00Z", want: false},
+		// the clocks go back at 03:00, the window lasts two hours of real time from 01:00 CEST
+		{name: "when the clocks go back", window: berlin, at: "2026-10-25T00:30:00Z", want: true},
+		{name: "after the clocks go back", window: berlin, at: "2026-10-25T01:00:00Z", want: false},
+		{name: "in a one-off window", window: oneOff, at: "2026-11-03T00:00:00Z", want: true},
+		{name: "after a one-off window", window: oneOff, at: "2026-11-03T01:00:00Z", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.window.Timezone != "" {
+				if _, err := time.LoadLocation(tt.window.Timezone); err != nil {
+					t.Skip("time zone database not available:", err)
+				}
+			}
+			at, err := time.Parse(time.RFC3339, tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.window.Active(at); got != tt.want {
+				t.Errorf("Active(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
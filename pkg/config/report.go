@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/wcy-dt/ponghub/protos/defaultConfig"
+)
+
+// Orders in which services are listed in the report
+const (
+	SortConfig       = "config"       // the order of the configuration files
+	SortName         = "name"         // service name
+	SortStatus       = "status"       // current status, failures first
+	SortAvailability = "availability" // availability, lowest first
+)
+
+// How services missing from the configuration are shown in the report
+const (
+	RetiredHide    = "hide"    // not shown
+	RetiredArchive = "archive" // shown in a collapsed archive after the other services
+)
+
+// How a partially available service or port counts in its availability
+const (
+	PartUp   = "up"   // as available
+	PartDown = "down" // as unavailable
+	PartHalf = "half" // as half available
+)
+
+// ReportConfig defines how the report is rendered. Set at the top level, it applies to every report target.
+type ReportConfig struct {
+	Sort         string `yaml:"sort,omitempty"`
+	Retired      string `yaml:"retired,omitempty"`
+	PartCountsAs string `yaml:"part_counts_as,omitempty"`
+
+	// UnknownAfter is the longest a checked state counts in the availability; the rest of a longer gap
+	// until the next check is unknown
+	UnknownAfter time.Duration `yaml:"unknown_after,omitempty"`
+
+	Timeline Timeline `yaml:"timeline,omitempty"`
+
+	// SiteURL is the address the reports are published at, linked from their feeds
+	SiteURL string `yaml:"site_url,omitempty"`
+}
+
+// ReportTarget defines a report generated by every run, such as a public status page
+// and an internal one, with the services and details it shows
+type ReportTarget struct {
+	Name     string `yaml:"name,omitempty"`
+	Output   string `yaml:"output"`
+	Template string `yaml:"template,omitempty"`
+
+	// Only lists key=value selectors of the services shown, as for the -only flag
+	Only []string `yaml:"only,omitempty"`
+	// HidePorts shows the services without their ports
+	HidePorts bool `yaml:"hide_ports,omitempty"`
+	// HideURLs shows the ports by name only, as if every port had hide_url set
+	HideURLs bool `yaml:"hide_urls,omitempty"`
+	// ShowDetails shows the status code, latency and error of the latest check of every port
+	ShowDetails bool `yaml:"show_details,omitempty"`
+	// HideLatency shows no latency charts of the ports
+	HideLatency bool `yaml:"hide_latency,omitempty"`
+	// Redact lists regular expressions whose matches are replaced in all texts of the report
+	Redact []string `yaml:"redact,omitempty"`
+
+	ReportConfig `yaml:",inline"`
+}
+
+// inherit fills the unset fields of the report settings from parent
+func (r *ReportConfig) inherit(parent *ReportConfig) {
+	if r.Sort == "" {
+		r.Sort = parent.Sort
+	}
+	if r.Retired == "" {
+		r.Retired = parent.Retired
+	}
+	if r.PartCountsAs == "" {
+		r.PartCountsAs = parent.PartCountsAs
+	}
+	if r.UnknownAfter == 0 {
+		r.UnknownAfter = parent.UnknownAfter
+	}
+	r.Timeline.inherit(&parent.Timeline)
+	if r.SiteURL == "" {
+		r.SiteURL = parent.SiteURL
+	}
+}
+
+// validate checks the values of the report settings
+func (r *ReportConfig) validate() error {
+	switch r.Sort {
+	case SortConfig, SortName, SortStatus, SortAvailability:
+	default:
+		return fmt.Errorf("report sort must be one of %s, %s, %s or %s, got %q",
+			SortConfig, SortName, SortStatus, SortAvailability, r.Sort)
+	}
+	switch r.Retired {
+	case RetiredHide, RetiredArchive:
+	default:
+		return fmt.Errorf("report retired must be %s or %s, got %q", RetiredHide, RetiredArchive, r.Retired)
+	}
+	if err := r.Timeline.validate(); err != nil {
+		return fmt.Errorf("report %w", err)
+	}
+	if r.UnknownAfter < 0 {
+		return fmt.Errorf("report unknown_after must be positive, got %s", r.UnknownAfter)
+	}
+	if r.SiteURL != "" {
+		if u, err := url.Parse(r.SiteURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("report site_url must be an http or https URL, got %q", r.SiteURL)
+		}
+	}
+	switch r.PartCountsAs {
+	case PartUp, PartDown, PartHalf:
+		return nil
+	default:
+		return fmt.Errorf("report part_counts_as must be %s, %s or %s, got %q", PartUp, PartDown, PartHalf, r.PartCountsAs)
+	}
+}
+
+// setDefaults fills the unset fields of the report target from the top-level report settings and the built-in defaults
+func (t *ReportTarget) setDefaults(parent *ReportConfig) {
+	if t.Template == "" {
+		t.Template = defaultConfig.GetTemplatePath()
+	}
+	t.ReportConfig.inherit(parent)
+}
+
+// validate checks the values of the report target
+func (t *ReportTarget) validate() error {
+	if t.Output == "" {
+		return errors.New("output is not set")
+	}
+	if _, err := t.Selector(); err != nil {
+		return err
+	}
+	for _, expr := range t.Redact {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid redact expression %q: %w", expr, err)
+		}
+	}
+	return t.ReportConfig.validate()
+}
+
+// Selector returns the selector of the services shown in the report
+func (t *ReportTarget) Selector() (Selector, error) {
+	s := Selector{}
+	for _, cond := range t.Only {
+		if err := s.Add(cond); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Redactor returns a function replacing the matches of the redact expressions of the report target
+func (t *ReportTarget) Redactor() func(string) string {
+	var exprs []*regexp.Regexp
+	for _, expr := range t.Redact {
+		// the expressions have been validated when loading
+		if re, err := regexp.Compile(expr); err == nil {
+			exprs = append(exprs, re)
+		}
+	}
+	return func(s string) string {
+		for _, re := range exprs {
+			s = re.ReplaceAllString(s, redactedValue)
+		}
+		return s
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/wcy-dt/ponghub/protos/defaultConfig"
+)
+
+// Retention defines how long the history is kept once its entries are older than max_log_days:
+// rolled up by hour until HourlyDays, then by day until DailyDays
+type Retention struct {
+	HourlyDays int `yaml:"hourly_days,omitempty"`
+	DailyDays  int `yaml:"daily_days,omitempty"`
+}
+
+// setDefaults sets the unset tiers to their default, or to the previous tier if it is longer
+func (r *Retention) setDefaults(maxLogDays int) {
+	if r.HourlyDays <= 0 {
+		r.HourlyDays = max(defaultConfig.GetDefaultHourlyDays(), maxLogDays)
+	}
+	if r.DailyDays <= 0 {
+		r.DailyDays = max(defaultConfig.GetDefaultDailyDays(), r.HourlyDays)
+	}
+}
+
+// validate checks that every tier keeps the history at least as long as the previous one
+func (r *Retention) validate(maxLogDays int) error {
+	if r.HourlyDays < maxLogDays {
+		return fmt.Errorf("retention hourly_days (%d) must not be less than max_log_days (%d)", r.HourlyDays, maxLogDays)
+	}
+	if r.DailyDays < r.HourlyDays {
+		return fmt.Errorf("retention daily_days (%d) must not be less than hourly_days (%d)", r.DailyDays, r.HourlyDays)
+	}
+	return nil
+}
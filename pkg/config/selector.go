@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// selectorKeys lists the fields services can be selected by
+var selectorKeys = []string{"tag", "group", "name"}
+
+// Selector selects the services of a run by tag, group or name. Values of the same key are alternatives,
+// while different keys must all match, so tag=prod, tag=staging and group=payments selects the
+// production and staging services of the payments group.
+type Selector map[string][]string
+
+// Add adds a condition written as key=value
+func (s Selector) Add(cond string) error {
+	key, value, ok := strings.Cut(cond, "=")
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	if !ok || value == "" {
+		return fmt.Errorf("invalid selector %q, expected key=value", cond)
+	}
+	if !slices.Contains(selectorKeys, key) {
+		return fmt.Errorf("invalid selector %q, key must be one of %s", cond, strings.Join(selectorKeys, ", "))
+	}
+	s[key] = append(s[key], value)
+	return nil
+}
+
+// Matches reports whether the service meets the conditions of the selector
+func (s Selector) Matches(svc *ServiceConfig) bool {
+	for key, values := range s {
+		var matched bool
+		switch key {
+		case "tag":
+			matched = slices.ContainsFunc(values, func(v string) bool { return slices.Contains(svc.Tags, v) })
+		case "group":
+			matched = slices.Contains(values, svc.Group)
+		case "name":
+			matched = slices.Contains(values, svc.Name)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the conditions of the selector in the form they are given on the command line
+func (s Selector) String() string {
+	var conds []string
+	for _, key := range selectorKeys {
+		for _, v := range s[key] {
+			conds = append(conds, key+"="+v)
+		}
+	}
+	return strings.Join(conds, ", ")
+}
+
+// Select returns a copy of the configuration with only the services matched by the selector
+func (cfg *Config) Select(s Selector) (*Config, error) {
+	if len(s) == 0 {
+		return cfg, nil
+	}
+	selected := *cfg
+	selected.Services = nil
+	for _, svc := range cfg.Services {
+		if s.Matches(&svc) {
+			selected.Services = append(selected.Services, svc)
+		}
+	}
+	if len(selected.Services) == 0 {
+		return nil, errors.New("no services match " + s.String())
+	}
+	return &selected, nil
+}
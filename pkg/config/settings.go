@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/wcy-dt/ponghub/protos/defaultConfig"
+	"github.com/wcy-dt/ponghub/protos/failureReason"
+)
+
+// CheckSettings defines the settings of a check that can be set globally, per service and per port.
+// Each level inherits the fields it does not set from the level above, and the global level from the
+// built-in defaults, so after loading every port holds its effective settings. Retry is the number of
+// attempts of a check, an explicit 0 turning retries off like 1.
+type CheckSettings struct {
+	Timeout         int               `yaml:"timeout,omitempty"`
+	Retry           *int              `yaml:"retry,omitempty"`
+	StatusCode      StatusCodes       `yaml:"status_code,omitempty"`
+	FollowRedirects *bool             `yaml:"follow_redirects,omitempty"`
+	MaxRedirects    int               `yaml:"max_redirects,omitempty"`
+	Headers         map[string]string `yaml:"headers,omitempty"`
+	TLS             *TLSConfig        `yaml:"tls,omitempty"`
+	RetryPolicy     *RetryPolicy      `yaml:"retry_policy,omitempty"`
+}
+
+// TLSConfig defines how the TLS certificate of a port is verified
+type TLSConfig struct {
+	InsecureSkipVerify *bool  `yaml:"insecure_skip_verify,omitempty"`
+	ServerName         string `yaml:"server_name,omitempty"`
+	CAFile             string `yaml:"ca_file,omitempty"`
+	MinVersion         string `yaml:"min_version,omitempty"`
+}
+
+// RetryPolicy defines how long to wait between the attempts of a port and which failures are retried.
+// The number of attempts is set by retry, and retrying stops early once MaxElapsed has passed.
+type RetryPolicy struct {
+	InitialInterval time.Duration `yaml:"initial_interval,omitempty"`
+	MaxInterval     time.Duration `yaml:"max_interval,omitempty"`
+	Multiplier      float64       `yaml:"multiplier,omitempty"`
+	Jitter          *float64      `yaml:"jitter,omitempty"`
+	MaxElapsed      time.Duration `yaml:"max_elapsed,omitempty"`
+	RetryOn         []string      `yaml:"retry_on,omitempty"`
+}
+
+// defaultSettings returns the built-in check settings
+func defaultSettings() *CheckSettings {
+	jitter := defaultConfig.GetDefaultRetryJitter()
+	retry := defaultConfig.GetDefaultRetry()
+	return &CheckSettings{
+		Timeout: defaultConfig.GetDefaultTimeout(),
+		Retry:   &retry,
+		RetryPolicy: &RetryPolicy{
+			InitialInterval: defaultConfig.GetDefaultRetryInitialInterval(),
+			MaxInterval:     defaultConfig.GetDefaultRetryMaxInterval(),
+			Multiplier:      defaultConfig.GetDefaultRetryMultiplier(),
+			Jitter:          &jitter,
+			RetryOn:         defaultConfig.GetDefaultRetryOn(),
+		},
+	}
+}
+
+// inherit fills the unset fields of the settings from parent; headers are merged, the own ones winning
+func (s *CheckSettings) inherit(parent *CheckSettings) {
+	if s.Timeout <= 0 {
+		s.Timeout = parent.Timeout
+	}
+	if s.Retry == nil {
+		s.Retry = parent.Retry
+	}
+	if len(s.StatusCode) == 0 {
+		s.StatusCode = parent.StatusCode
+	}
+	if s.FollowRedirects == nil {
+		s.FollowRedirects = parent.FollowRedirects
+	}
+	if s.MaxRedirects <= 0 {
+		s.MaxRedirects = parent.MaxRedirects
+	}
+	if len(parent.Headers) > 0 {
+		headers := maps.Clone(parent.Headers)
+		maps.Copy(headers, s.Headers)
+		s.Headers = headers
+	}
+	switch {
+	case s.TLS == nil:
+		s.TLS = parent.TLS
+	case parent.TLS != nil:
+		s.TLS.inherit(parent.TLS)
+	}
+	switch {
+	case s.RetryPolicy == nil:
+		s.RetryPolicy = parent.RetryPolicy
+	case parent.RetryPolicy != nil:
+		s.RetryPolicy.inherit(parent.RetryPolicy)
+	}
+}
+
+// validate checks the values of the settings
+func (s *CheckSettings) validate() error {
+	if s.Retry != nil && *s.Retry < 0 {
+		return fmt.Errorf("retry must not be negative, got %d", *s.Retry)
+	}
+	if s.MaxRedirects < 0 {
+		return fmt.Errorf("max_redirects must not be negative, got %d", s.MaxRedirects)
+	}
+	if s.TLS != nil {
+		if err := s.TLS.validate(); err != nil {
+			return err
+		}
+	}
+	if s.RetryPolicy != nil {
+		return s.RetryPolicy.validate()
+	}
+	return nil
+}
+
+// inherit fills the unset fields of the TLS settings from parent
+func (t *TLSConfig) inherit(parent *TLSConfig) {
+	if t.InsecureSkipVerify == nil {
+		t.InsecureSkipVerify = parent.InsecureSkipVerify
+	}
+	if t.ServerName == "" {
+		t.ServerName = parent.ServerName
+	}
+	if t.CAFile == "" {
+		t.CAFile = parent.CAFile
+	}
+	if t.MinVersion == "" {
+		t.MinVersion = parent.MinVersion
+	}
+}
+
+// validate checks the values of the TLS settings
+func (t *TLSConfig) validate() error {
+	switch t.MinVersion {
+	case "", "1.0", "1.1", "1.2", "1.3":
+		return nil
+	default:
+		return fmt.Errorf("tls min_version must be one of 1.0, 1.1, 1.2 or 1.3, got %q", t.MinVersion)
+	}
+}
+
+// inherit fills the unset fields of the policy from parent
+func (p *RetryPolicy) inherit(parent *RetryPolicy) {
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = parent.InitialInterval
+	}
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = parent.MaxInterval
+	}
+	if p.Multiplier <= 0 {
+		p.Multiplier = parent.Multiplier
+	}
+	if p.Jitter == nil {
+		p.Jitter = parent.Jitter
+	}
+	if p.MaxElapsed <= 0 {
+		p.MaxElapsed = parent.MaxElapsed
+	}
+	if len(p.RetryOn) == 0 {
+		p.RetryOn = parent.RetryOn
+	}
+}
+
+// validate checks the values of the policy
+func (p *RetryPolicy) validate() error {
+	if p.Multiplier != 0 && p.Multiplier < 1 {
+		return fmt.Errorf("retry_policy multiplier must be at least 1, got %g", p.Multiplier)
+	}
+	if p.Jitter != nil && (*p.Jitter < 0 || *p.Jitter > 1) {
+		return fmt.Errorf("retry_policy jitter must be between 0 and 1, got %g", *p.Jitter)
+	}
+	for _, r := range p.RetryOn {
+		if !failureReason.FailureReason(r).IsValid() {
+			return fmt.Errorf("retry_policy retry_on has unknown reason %q", r)
+		}
+	}
+	return nil
+}
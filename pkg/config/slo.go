@@ -0,0 +1,18 @@
+package config
+
+import "fmt"
+
+// SLOConfig defines a service level objective: the percentage of time a service should be available
+// over a rolling window of days
+type SLOConfig struct {
+	Target     float64 `yaml:"target"`
+	WindowDays int     `yaml:"window_days,omitempty"`
+}
+
+// validate checks that the target is a percentage below 100
+func (s *SLOConfig) validate() error {
+	if s.Target <= 0 || s.Target >= 100 {
+		return fmt.Errorf("slo target must be a percentage between 0 and 100, got %v", s.Target)
+	}
+	return nil
+}
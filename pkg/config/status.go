@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StatusRange defines an inclusive range of HTTP status codes
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// StatusCodes defines the HTTP status codes accepted for a port. In YAML it is written as a single code,
+// a range such as "200-299" or "2xx", or a list of both such as [200, 204, "300-399"]. The code 0 sets
+// no constraint, so that the inherited or default status codes apply.
+type StatusCodes []StatusRange
+
+// parseStatusRange parses a single status code, a range "min-max", or a class such as "2xx"
+func parseStatusRange(s string) (StatusRange, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == 3 && strings.HasSuffix(strings.ToLower(s), "xx") && s[0] >= '1' && s[0] <= '5' {
+		class := int(s[0]-'0') * 100
+		return StatusRange{Min: class, Max: class + 99}, nil
+	}
+
+	minStr, maxStr, isRange := strings.Cut(s, "-")
+	minCode, err := strconv.Atoi(strings.TrimSpace(minStr))
+	if err != nil {
+		return StatusRange{}, fmt.Errorf("invalid status code %q", s)
+	}
+	maxCode := minCode
+	if isRange {
+		if maxCode, err = strconv.Atoi(strings.TrimSpace(maxStr)); err != nil {
+			return StatusRange{}, fmt.Errorf("invalid status code range %q", s)
+		}
+	}
+	if minCode < 100 || maxCode > 599 || minCode > maxCode {
+		return StatusRange{}, fmt.Errorf("invalid status code range %q", s)
+	}
+	return StatusRange{Min: minCode, Max: maxCode}, nil
+}
+
+// UnmarshalYAML decodes a single status code, a range, or a list of both
+func (sc *StatusCodes) UnmarshalYAML(value *yaml.Node) error {
+	var items []string
+	switch value.Kind {
+	case yaml.ScalarNode:
+		items = []string{value.Value}
+	case yaml.SequenceNode:
+		for _, n := range value.Content {
+			if n.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: status_code list items must be codes or ranges", n.Line)
+			}
+			items = append(items, n.Value)
+		}
+	default:
+		return fmt.Errorf("line %d: status_code must be a code, a range or a list", value.Line)
+	}
+
+	codes := StatusCodes{}
+	for _, item := range items {
+		// 0 leaves the status codes unset, as before ranges were accepted
+		if strings.TrimSpace(item) == "0" {
+			continue
+		}
+		r, err := parseStatusRange(item)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", value.Line, err)
+		}
+		codes = append(codes, r)
+	}
+	*sc = codes
+	return nil
+}
+
+// MarshalYAML encodes the status codes as a single code or range if possible, otherwise as a list
+func (sc StatusCodes) MarshalYAML() (any, error) {
+	items := make([]any, 0, len(sc))
+	for _, r := range sc {
+		if r.Min == r.Max {
+			items = append(items, r.Min)
+		} else {
+			items = append(items, fmt.Sprintf("%d-%d", r.Min, r.Max))
+		}
+	}
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	return items, nil
+}
+
+// Contains reports whether the status code is accepted
+func (sc StatusCodes) Contains(code int) bool {
+	for _, r := range sc {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the status codes in the same form as in the configuration file
+func (sc StatusCodes) String() string {
+	var items []string
+	for _, r := range sc {
+		if r.Min == r.Max {
+			items = append(items, strconv.Itoa(r.Min))
+		} else {
+			items = append(items, fmt.Sprintf("%d-%d", r.Min, r.Max))
+		}
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"slices"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseStatusRange(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    StatusRange
+		wantErr bool
+	}{
+		{in: "200", want: StatusRange{200, 200}},
+		{in: " 204 ", want: StatusRange{204, 204}},
+		{in: "200-299", want: StatusRange{200, 299}},
+		{in: "200 - 204", want: StatusRange{200, 204}},
+		{in: "2xx", want: StatusRange{200, 299}},
+		{in: "5XX", want: StatusRange{500, 599}},
+		{in: "1xx", want: StatusRange{100, 199}},
+		{in: "100", want: StatusRange{100, 100}},
+		{in: "599", want: StatusRange{599, 599}},
+		{in: "404-404", want: StatusRange{404, 404}},
+		{in: "99", wantErr: true},
+		{in: "600", wantErr: true},
+		{in: "0xx", wantErr: true},
+		{in: "6xx", wantErr: true},
+		{in: "2x", wantErr: true},
+		{in: "299-200", wantErr: true},
+		{in: "200-600", wantErr: true},
+		{in: "-200", wantErr: true},
+		{in: "200-", wantErr: true},
+		{in: "ok", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseStatusRange(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseStatusRange(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseStatusRange(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStatusCodesYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    StatusCodes
+		wantOut string // the status codes written back as YAML
+		wantErr bool
+	}{
+		{name: "code", in: "200", want: StatusCodes{{200, 200}}, wantOut: "200\n"},
+		{name: "range", in: `"200-299"`, want: StatusCodes{{200, 299}}, wantOut: "200-299\n"},
+		{name: "class", in: "3xx", want: StatusCodes{{300, 399}}, wantOut: "300-399\n"},
+		{name: "list", in: `[200, 204, "300-399"]`, want: StatusCodes{{200, 200}, {204, 204}, {300, 399}}, wantOut: "- 200\n- 204\n- 300-399\n"},
+		{name: "unset", in: "0", want: StatusCodes{}, wantOut: "[]\n"},
+		{name: "unset in a list", in: "[0, 204]", want: StatusCodes{{204, 204}}, wantOut: "204\n"},
+		{name: "invalid item", in: "[200, 700]", wantErr: true},
+		{name: "nested list", in: "[[200]]", wantErr: true},
+		{name: "mapping", in: "{min: 200}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got StatusCodes
+			err := yaml.Unmarshal([]byte(tt.in), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Unmarshal(%s) = %v, want %v", tt.in, got, tt.want)
+			}
+			out, err := yaml.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.wantOut {
+				t.Errorf("Marshal(%v) = %q, want %q", got, out, tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestStatusCodesContains(t *testing.T) {
+	codes := StatusCodes{{200, 200}, {204, 204}, {300, 399}}
+	tests := []struct {
+		code int
+		want bool
+	}{
+		{200, true},
+		{201, false},
+		{204, true},
+		{299, false},
+		{300, true},
+		{399, true},
+		{400, false},
+		{0, false},
+	}
+	for _, tt := range tests {
+		if got := codes.Contains(tt.code); got != tt.want {
+			t.Errorf("Contains(%d) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+	if got, want := codes.String(), "200, 204, 300-399"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if (StatusCodes{}).Contains(200) {
+		t.Error("empty status codes contain 200")
+	}
+}
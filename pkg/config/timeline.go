@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Colorings of the buckets of the report timeline
+const (
+	ColorWorst      = "worst"      // the worst status checked in the bucket
+	ColorPercentage = "percentage" // from red to green by the share of successful checks in the bucket
+)
+
+// maxTimelineBuckets bounds the number of buckets of the timeline, each rendered for every service and port
+const maxTimelineBuckets = 500
+
+// Period is a duration written as a Go duration such as "20m" or "36h", or as a number of days such as "90d"
+type Period time.Duration
+
+// UnmarshalYAML decodes a Go duration or a number of days
+func (p *Period) UnmarshalYAML(value *yaml.Node) error {
+	if days, ok := strings.CutSuffix(value.Value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("line %d: invalid number of days %q", value.Line, value.Value)
+		}
+		*p = Period(time.Duration(n) * 24 * time.Hour)
+		return nil
+	}
+	d, err := time.ParseDuration(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*p = Period(d)
+	return nil
+}
+
+// MarshalYAML encodes the period in days if it is a whole number of them
+func (p Period) MarshalYAML() (any, error) {
+	return p.String(), nil
+}
+
+// String returns the period in days if it is a whole number of them, otherwise as a Go duration
+func (p Period) String() string {
+	d := time.Duration(p)
+	if day := 24 * time.Hour; d > 0 && d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	return d.String()
+}
+
+// Timeline defines the status bar of every service and port in the report: Span is the period it shows,
+// split into buckets of Bucket, the last one holding the latest checks
+type Timeline struct {
+	Span   Period `yaml:"span,omitempty"`
+	Bucket Period `yaml:"bucket,omitempty"`
+	Color  string `yaml:"color,omitempty"`
+}
+
+// inherit fills the unset fields of the timeline from parent
+func (t *Timeline) inherit(parent *Timeline) {
+	if t.Span == 0 {
+		t.Span = parent.Span
+	}
+	if t.Bucket == 0 {
+		t.Bucket = parent.Bucket
+	}
+	if t.Color == "" {
+		t.Color = parent.Color
+	}
+}
+
+// Buckets returns the number of buckets of the timeline
+func (t *Timeline) Buckets() int {
+	return int(t.Span / t.Bucket)
+}
+
+// validate checks that the span is a whole number of buckets, and not too many
+func (t *Timeline) validate() error {
+	switch {
+	case t.Bucket <= 0 || t.Span <= 0:
+		return fmt.Errorf("timeline span and bucket must be positive, got %s and %s", t.Span, t.Bucket)
+	case t.Span%t.Bucket != 0:
+		return fmt.Errorf("timeline span %s must be a whole number of buckets of %s", t.Span, t.Bucket)
+	case t.Buckets() > maxTimelineBuckets:
+		return fmt.Errorf("timeline span %s holds %d buckets of %s, at most %d are allowed", t.Span, t.Buckets(), t.Bucket, maxTimelineBuckets)
+	}
+	switch t.Color {
+	case ColorWorst, ColorPercentage:
+		return nil
+	default:
+		return fmt.Errorf("timeline color must be %s or %s, got %q", ColorWorst, ColorPercentage, t.Color)
+	}
+}
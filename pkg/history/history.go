@@ -0,0 +1,173 @@
+// Package history keeps the status log of every service and port across runs.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/checker"
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// Entry defines the status of a service or port at a point in time.
+// Port entries also record the status code, latency and error of the check.
+type Entry struct {
+	Time       string                `json:"time"`
+	Online     testResult.TestResult `json:"online"`
+	StatusCode int                   `json:"status_code,omitempty"`
+	LatencyMs  int64                 `json:"latency_ms,omitempty"`
+	Error      string                `json:"error,omitempty"`
+	Metrics    []checker.Metric      `json:"metrics,omitempty"` // the performance data of a plugin
+}
+
+// ServiceLog defines the history of a service and of each of its ports, keyed by port identity.
+// The history older than max_log_days is kept as rollups.
+type ServiceLog struct {
+	ServiceHistory []Entry             `json:"service_history"`
+	Ports          map[string][]Entry  `json:"ports"`
+	Rollups        *Rollups            `json:"rollups,omitempty"`
+	PortRollups    map[string]*Rollups `json:"port_rollups,omitempty"`
+
+	// Retired is the time the service was first found missing from the configuration, empty while it is configured
+	Retired string `json:"retired,omitempty"`
+}
+
+// Log defines the history of all services, keyed by service name
+type Log map[string]*ServiceLog
+
+// MergeOnlineStatus merges a list of online statuses into a single status
+func MergeOnlineStatus(statusList []testResult.TestResult) testResult.TestResult {
+	if len(statusList) == 0 {
+		return testResult.NONE
+	}
+
+	hasNone, hasAll, hasKnown, hasCancelled, hasMaintenance := false, false, false, false, false
+	for _, s := range statusList {
+		switch s {
+		case testResult.NONE:
+			hasNone = true
+		case testResult.ALL:
+			hasAll = true
+		case testResult.CANCELLED:
+			hasCancelled = true
+		case testResult.MAINTENANCE:
+			hasMaintenance = true
+		}
+		if s.IsValid() {
+			hasKnown = true
+		}
+	}
+
+	switch {
+	case hasCancelled:
+		return testResult.CANCELLED
+	case hasMaintenance:
+		return testResult.MAINTENANCE
+	case !hasKnown:
+		return testResult.UNKNOWN
+	case hasNone && !hasAll:
+		return testResult.NONE
+	case !hasNone && hasAll:
+		return testResult.ALL
+	default:
+		return testResult.PART
+	}
+}
+
+// Load reads the log file at path, returning an empty log if the file does not exist yet
+func Load(path string) (Log, error) {
+	logData := Log{}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return logData, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	if err := json.Unmarshal(b, &logData); err != nil {
+		return nil, fmt.Errorf("failed to parse log file: %w", err)
+	}
+	return logData, nil
+}
+
+// Save writes the log to the file at path
+func (l Log) Save(path string) error {
+	logBytes, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, logBytes, 0644); err != nil {
+		return fmt.Errorf("failed to write log file: %w", err)
+	}
+	return nil
+}
+
+// Update appends the check results to the log
+func (l Log) Update(results []checker.CheckResult) {
+	for _, svc := range results {
+		// check if service already exists in the log, otherwise initialize it
+		svcLog, ok := l[svc.Name]
+		if !ok || svcLog == nil {
+			svcLog = &ServiceLog{}
+			l[svc.Name] = svcLog
+		}
+		if svcLog.Ports == nil {
+			svcLog.Ports = map[string][]Entry{}
+		}
+
+		svcLog.ServiceHistory = append(svcLog.ServiceHistory, Entry{
+			Time:   svc.StartTime,
+			Online: svc.Online,
+		})
+
+		// Only record one port entry for each port identity per complete run,
+		// with the details of its first failing check if any, otherwise of its first check
+		idStatusMap := map[string][]testResult.TestResult{}
+		idTimeMap := map[string]string{}
+		idDetailMap := map[string]checker.PortResult{}
+		for _, pr := range slices.Concat(svc.Health, svc.API) {
+			idStatusMap[pr.ID] = append(idStatusMap[pr.ID], pr.Online)
+			if idTimeMap[pr.ID] == "" {
+				idTimeMap[pr.ID] = pr.StartTime
+			}
+			if detail, ok := idDetailMap[pr.ID]; !ok || (detail.Online == testResult.ALL && pr.Online != testResult.ALL) {
+				idDetailMap[pr.ID] = pr
+			}
+		}
+		for id, statusList := range idStatusMap {
+			detail := idDetailMap[id]
+			entry := Entry{
+				Time:       idTimeMap[id],
+				Online:     MergeOnlineStatus(statusList),
+				StatusCode: detail.StatusCode,
+				LatencyMs:  detail.LatencyMs,
+				Metrics:    detail.Metrics,
+			}
+			if len(detail.Failures) > 0 && detail.Online != testResult.ALL {
+				entry.Error = detail.Failures[len(detail.Failures)-1]
+			}
+			svcLog.Ports[id] = append(svcLog.Ports[id], entry)
+		}
+	}
+}
+
+// OutputResults adds the check results to the log file at path, after moving the history of renamed services,
+// then retires the services missing from the configuration and rolls up the records older than max_log_days
+func OutputResults(path string, cfg *config.Config, results []checker.CheckResult) error {
+	logData, err := Load(path)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	logData.Rename(cfg)
+	logData.Update(results)
+	logData.Retire(cfg, now)
+	logData.Compact(cfg, now)
+	return logData.Save(path)
+}
@@ -0,0 +1,50 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wcy-dt/ponghub/pkg/checker"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+func TestUpdate(t *testing.T) {
+	metrics := []checker.Metric{{Label: "load", Value: 0.5, Warn: "1", Crit: "2"}}
+	results := []checker.CheckResult{{
+		Name:      "Worker",
+		Online:    testResult.PART,
+		StartTime: "2026-10-19T10:00:00Z",
+		Health: []checker.PortResult{
+			{ID: "load", Online: testResult.ALL, StartTime: "2026-10-19T10:00:01Z", LatencyMs: 20, Metrics: metrics},
+			{ID: "disk", Online: testResult.ALL, StartTime: "2026-10-19T10:00:02Z", LatencyMs: 30},
+		},
+		API: []checker.PortResult{
+			// the same port checked again in the run: its failure is recorded
+			{ID: "disk", Online: testResult.NONE, StartTime: "2026-10-19T10:00:03Z", LatencyMs: 40,
+				Failures: []string{"first", "last"}, Metrics: []checker.Metric{{Label: "free", Value: 1, Unit: "%"}}},
+		},
+	}}
+
+	l := Log{}
+	l.Update(results)
+	svc := l["Worker"]
+	if svc == nil {
+		t.Fatal("Update() did not add the service")
+	}
+	if want := []Entry{{Time: "2026-10-19T10:00:00Z", Online: testResult.PART}}; !reflect.DeepEqual(svc.ServiceHistory, want) {
+		t.Errorf("service history = %+v, want %+v", svc.ServiceHistory, want)
+	}
+	tests := []struct {
+		id   string
+		want []Entry
+	}{
+		{id: "load", want: []Entry{{Time: "2026-10-19T10:00:01Z", Online: testResult.ALL, LatencyMs: 20, Metrics: metrics}}},
+		{id: "disk", want: []Entry{{Time: "2026-10-19T10:00:02Z", Online: testResult.PART, LatencyMs: 40, Error: "last",
+			Metrics: []checker.Metric{{Label: "free", Value: 1, Unit: "%"}}}}},
+	}
+	for _, tt := range tests {
+		if got := svc.Ports[tt.id]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("port %s = %+v, want %+v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package history
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+)
+
+// mergeEntries merges two histories in time order
+func mergeEntries(a, b []Entry) []Entry {
+	merged := slices.Concat(a, b)
+	slices.SortStableFunc(merged, func(x, y Entry) int { return cmp.Compare(x.Time, y.Time) })
+	return merged
+}
+
+// sortedKeys returns the keys of m in name order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Rename moves the history kept under the previous names of every service to its current name,
+// merging it with the history already kept under the current name
+func (l Log) Rename(cfg *config.Config) {
+	for _, svc := range cfg.Services {
+		for _, prev := range svc.PreviousNames {
+			old, ok := l[prev]
+			if !ok || old == nil {
+				continue
+			}
+			delete(l, prev)
+
+			cur, ok := l[svc.Name]
+			if !ok || cur == nil {
+				old.Retired = ""
+				l[svc.Name] = old
+				continue
+			}
+			cur.ServiceHistory = mergeEntries(old.ServiceHistory, cur.ServiceHistory)
+			cur.Rollups = mergeAllRollups(old.Rollups, cur.Rollups)
+			if cur.Ports == nil {
+				cur.Ports = map[string][]Entry{}
+			}
+			for url, history := range old.Ports {
+				cur.Ports[url] = mergeEntries(history, cur.Ports[url])
+			}
+			for id, rollups := range old.PortRollups {
+				if cur.PortRollups == nil {
+					cur.PortRollups = map[string]*Rollups{}
+				}
+				cur.PortRollups[id] = mergeAllRollups(rollups, cur.PortRollups[id])
+			}
+		}
+	}
+}
+
+// Retire marks the services missing from the configuration as retired and revives the ones configured again.
+// Retired services are no longer updated, and are dropped by Compact once their history is gone.
+func (l Log) Retire(cfg *config.Config, now time.Time) {
+	configured := map[string]bool{}
+	for _, svc := range cfg.Services {
+		configured[svc.Name] = true
+	}
+
+	for name, svcLog := range l {
+		if svcLog == nil {
+			delete(l, name)
+			continue
+		}
+		if configured[name] {
+			svcLog.Retired = ""
+		} else if svcLog.Retired == "" {
+			svcLog.Retired = now.Format(time.RFC3339)
+		}
+	}
+}
+
+// Prune drops the services and ports missing from the configuration, after moving the history of
+// renamed services, and returns a description of everything dropped
+func (l Log) Prune(cfg *config.Config) []string {
+	l.Rename(cfg)
+
+	identities := map[string][]string{}
+	for _, svc := range cfg.Services {
+		identities[svc.Name] = []string{}
+		for _, p := range slices.Concat(svc.Health, svc.API) {
+			// the log holds the identities with their secrets redacted
+			identities[svc.Name] = append(identities[svc.Name], cfg.Redact(p.Identity()))
+		}
+	}
+
+	var dropped []string
+	for _, name := range sortedKeys(l) {
+		configured, ok := identities[name]
+		if !ok {
+			delete(l, name)
+			dropped = append(dropped, fmt.Sprintf("service %q", name))
+			continue
+		}
+		if l[name] == nil {
+			continue
+		}
+		for _, id := range sortedKeys(l[name].PortSeries()) {
+			if !slices.Contains(configured, id) {
+				delete(l[name].Ports, id)
+				delete(l[name].PortRollups, id)
+				dropped = append(dropped, fmt.Sprintf("port %s of service %q", id, name))
+			}
+		}
+	}
+	return dropped
+}
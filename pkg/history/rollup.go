@@ -0,0 +1,311 @@
+package history
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// Latency summarizes the latencies of the checks of a rollup. The percentiles of rollups merged
+// into a longer period are the averages of theirs, weighted by their number of checks.
+type Latency struct {
+	Count int   `json:"count"`
+	P50   int64 `json:"p50"`
+	P95   int64 `json:"p95"`
+	P99   int64 `json:"p99"`
+	Max   int64 `json:"max"`
+}
+
+// Rollup defines the checks of a service or port over an hour or a day starting at Time,
+// kept in place of their entries once these are older than max_log_days
+type Rollup struct {
+	Time    string                        `json:"time"`
+	Counts  map[testResult.TestResult]int `json:"counts"`
+	Latency *Latency                      `json:"latency,omitempty"`
+}
+
+// Rollups defines the hourly and daily rollups of a service or port
+type Rollups struct {
+	Hourly []Rollup `json:"hourly,omitempty"`
+	Daily  []Rollup `json:"daily,omitempty"`
+}
+
+// Series defines the history of a service or port across the retention tiers, each in time order:
+// the daily rollups come first, then the hourly rollups, then the entries
+type Series struct {
+	Daily   []Rollup
+	Hourly  []Rollup
+	Entries []Entry
+}
+
+// Total returns the number of checks of the rollup
+func (r *Rollup) Total() int {
+	total := 0
+	for _, n := range r.Counts {
+		total += n
+	}
+	return total
+}
+
+// Start returns the time the series begins, or the zero time if it is empty
+func (s *Series) Start() time.Time {
+	var first string
+	switch {
+	case len(s.Daily) > 0:
+		first = s.Daily[0].Time
+	case len(s.Hourly) > 0:
+		first = s.Hourly[0].Time
+	case len(s.Entries) > 0:
+		first = s.Entries[0].Time
+	}
+	t, _ := time.Parse(time.RFC3339, first)
+	return t
+}
+
+// series returns the series of the entries and rollups
+func series(entries []Entry, rollups *Rollups) Series {
+	s := Series{Entries: entries}
+	if rollups != nil {
+		s.Daily, s.Hourly = rollups.Daily, rollups.Hourly
+	}
+	return s
+}
+
+// ServiceSeries returns the history of the service across the retention tiers
+func (s *ServiceLog) ServiceSeries() Series {
+	return series(s.ServiceHistory, s.Rollups)
+}
+
+// PortSeries returns the history of every port of the service across the retention tiers, keyed by port identity
+func (s *ServiceLog) PortSeries() map[string]Series {
+	ports := map[string]Series{}
+	for id, entries := range s.Ports {
+		ports[id] = series(entries, s.PortRollups[id])
+	}
+	for id, rollups := range s.PortRollups {
+		if _, ok := ports[id]; !ok {
+			ports[id] = series(nil, rollups)
+		}
+	}
+	return ports
+}
+
+// truncateHour returns the start of the hour of t in UTC
+func truncateHour(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Hour)
+}
+
+// truncateDay returns the start of the day of t in UTC
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// percentile returns the smallest of the sorted values not exceeded by the fraction q of them
+func percentile(sorted []int64, q float64) int64 {
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[max(0, min(i, len(sorted)-1))]
+}
+
+// add merges into r the rollup o of the same period
+func (r *Rollup) add(o Rollup) {
+	if r.Counts == nil {
+		r.Counts = map[testResult.TestResult]int{}
+	}
+	for status, n := range o.Counts {
+		r.Counts[status] += n
+	}
+	r.Latency = r.Latency.Merge(o.Latency)
+}
+
+// NewLatency summarizes the latencies, or returns nil if there are none
+func NewLatency(latencies []int64) *Latency {
+	if len(latencies) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	return &Latency{
+		Count: len(sorted),
+		P50:   percentile(sorted, 0.50),
+		P95:   percentile(sorted, 0.95),
+		P99:   percentile(sorted, 0.99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// Merge returns the latencies of l and o merged, either of which may be nil
+func (l *Latency) Merge(o *Latency) *Latency {
+	switch {
+	case o == nil:
+		return l
+	case l == nil:
+		merged := *o
+		return &merged
+	}
+	weighted := func(x, y int64) int64 {
+		if l.Count+o.Count <= 0 {
+			// latencies without a number of checks, as edited by hand, weigh the same
+			return (x + y) / 2
+		}
+		return (x*int64(l.Count) + y*int64(o.Count)) / int64(l.Count+o.Count)
+	}
+	return &Latency{
+		Count: l.Count + o.Count,
+		P50:   weighted(l.P50, o.P50),
+		P95:   weighted(l.P95, o.P95),
+		P99:   weighted(l.P99, o.P99),
+		Max:   max(l.Max, o.Max),
+	}
+}
+
+// mergeRollups merges the rollups by period, in time order
+func mergeRollups(rollups ...[]Rollup) []Rollup {
+	var merged []Rollup
+	index := map[string]int{}
+	for _, r := range slices.Concat(rollups...) {
+		i, ok := index[r.Time]
+		if !ok {
+			index[r.Time] = len(merged)
+			merged = append(merged, Rollup{Time: r.Time})
+			i = len(merged) - 1
+		}
+		merged[i].add(r)
+	}
+	slices.SortFunc(merged, func(a, b Rollup) int { return cmp.Compare(a.Time, b.Time) })
+	return merged
+}
+
+// mergeAllRollups merges the hourly and daily rollups of a and b, either of which may be nil
+func mergeAllRollups(a, b *Rollups) *Rollups {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return &Rollups{Hourly: mergeRollups(a.Hourly, b.Hourly), Daily: mergeRollups(a.Daily, b.Daily)}
+}
+
+// rollupEntries aggregates the entries into a rollup per period, starting at their times truncated by trunc
+func rollupEntries(entries []Entry, trunc func(time.Time) time.Time) []Rollup {
+	var rollups []Rollup
+	index := map[string]int{}
+	var latencies [][]int64
+	for _, e := range entries {
+		t, err := time.Parse(time.RFC3339, e.Time)
+		if err != nil {
+			continue
+		}
+		key := trunc(t).Format(time.RFC3339)
+		i, ok := index[key]
+		if !ok {
+			i = len(rollups)
+			index[key] = i
+			rollups = append(rollups, Rollup{Time: key, Counts: map[testResult.TestResult]int{}})
+			latencies = append(latencies, nil)
+		}
+		rollups[i].Counts[e.Online]++
+		if e.LatencyMs > 0 {
+			latencies[i] = append(latencies[i], e.LatencyMs)
+		}
+	}
+	for i, l := range latencies {
+		rollups[i].Latency = NewLatency(l)
+	}
+	return rollups
+}
+
+// rollupRollups aggregates the rollups into a rollup per longer period, starting at their times truncated by trunc
+func rollupRollups(rollups []Rollup, trunc func(time.Time) time.Time) []Rollup {
+	var truncated []Rollup
+	for _, r := range rollups {
+		t, err := time.Parse(time.RFC3339, r.Time)
+		if err != nil {
+			continue
+		}
+		r.Time = trunc(t).Format(time.RFC3339)
+		truncated = append(truncated, r)
+	}
+	return mergeRollups(truncated)
+}
+
+// before splits the items into the ones whose time is before cutoff and the others
+func before[T any](items []T, timeOf func(T) string, cutoff time.Time) (older, newer []T) {
+	for _, item := range items {
+		t, err := time.Parse(time.RFC3339, timeOf(item))
+		if err != nil {
+			continue
+		}
+		if t.Before(cutoff) {
+			older = append(older, item)
+		} else {
+			newer = append(newer, item)
+		}
+	}
+	return older, newer
+}
+
+// compact rolls up the entries older than maxLogDays before now by hour, rolls up the hourly rollups older
+// than the hourly retention by day and drops the daily rollups older than the daily retention. Only whole
+// hours and days are rolled up, so that their entries are aggregated at once.
+func compact(entries []Entry, rollups *Rollups, now time.Time, maxLogDays int, retention config.Retention) ([]Entry, *Rollups) {
+	if rollups == nil {
+		rollups = &Rollups{}
+	}
+	entryTime := func(e Entry) string { return e.Time }
+	rollupTime := func(r Rollup) string { return r.Time }
+
+	expired, entries := before(entries, entryTime, truncateHour(now.AddDate(0, 0, -maxLogDays)))
+	hourly := mergeRollups(rollups.Hourly, rollupEntries(expired, truncateHour))
+
+	expiredHourly, hourly := before(hourly, rollupTime, truncateDay(now.AddDate(0, 0, -retention.HourlyDays)))
+	daily := mergeRollups(rollups.Daily, rollupRollups(expiredHourly, truncateDay))
+
+	_, daily = before(daily, rollupTime, truncateDay(now.AddDate(0, 0, -retention.DailyDays)))
+
+	if len(hourly) == 0 && len(daily) == 0 {
+		return entries, nil
+	}
+	return entries, &Rollups{Hourly: hourly, Daily: daily}
+}
+
+// Compact rolls up the history of every service older than max_log_days by hour and then by day, keeping the
+// rollups as long as set by the retention, and drops the ports and retired services with no history left
+func (l Log) Compact(cfg *config.Config, now time.Time) {
+	for name, svcLog := range l {
+		if svcLog == nil {
+			delete(l, name)
+			continue
+		}
+		svcLog.ServiceHistory, svcLog.Rollups = compact(svcLog.ServiceHistory, svcLog.Rollups, now, cfg.MaxLogDays, cfg.Retention)
+
+		if svcLog.Ports == nil {
+			svcLog.Ports = map[string][]Entry{}
+		}
+		rollups := map[string]*Rollups{}
+		for id := range svcLog.PortSeries() {
+			entries, r := compact(svcLog.Ports[id], svcLog.PortRollups[id], now, cfg.MaxLogDays, cfg.Retention)
+			if r != nil {
+				rollups[id] = r
+			}
+			if len(entries) > 0 {
+				svcLog.Ports[id] = entries
+			} else {
+				delete(svcLog.Ports, id)
+			}
+		}
+		svcLog.PortRollups = nil
+		if len(rollups) > 0 {
+			svcLog.PortRollups = rollups
+		}
+
+		if svcLog.Retired != "" && len(svcLog.ServiceHistory) == 0 && svcLog.Rollups == nil && len(svcLog.Ports) == 0 && svcLog.PortRollups == nil {
+			delete(l, name)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// sequence returns the latencies from 1 to n ms
+func sequence(n int) []int64 {
+	values := make([]int64, n)
+	for i := range values {
+		values[i] = int64(i + 1)
+	}
+	return values
+}
+
+func TestNewLatency(t *testing.T) {
+	tests := []struct {
+		name      string
+		latencies []int64
+		want      *Latency
+	}{
+		{name: "none", latencies: nil, want: nil},
+		{name: "single", latencies: []int64{42}, want: &Latency{Count: 1, P50: 42, P95: 42, P99: 42, Max: 42}},
+		{name: "two", latencies: []int64{10, 20}, want: &Latency{Count: 2, P50: 10, P95: 20, P99: 20, Max: 20}},
+		{name: "unsorted", latencies: []int64{30, 10, 20}, want: &Latency{Count: 3, P50: 20, P95: 30, P99: 30, Max: 30}},
+		{name: "hundred", latencies: sequence(100), want: &Latency{Count: 100, P50: 50, P95: 95, P99: 99, Max: 100}},
+		{name: "outlier", latencies: append(sequence(99), 5000), want: &Latency{Count: 100, P50: 50, P95: 95, P99: 99, Max: 5000}},
+		{name: "thousand", latencies: sequence(1000), want: &Latency{Count: 1000, P50: 500, P95: 950, P99: 990, Max: 1000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := append([]int64(nil), tt.latencies...)
+			got := NewLatency(tt.latencies)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewLatency() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.latencies, input) {
+				t.Errorf("NewLatency() sorted its input: %v", tt.latencies)
+			}
+		})
+	}
+}
+
+func TestLatencyMerge(t *testing.T) {
+	fast := &Latency{Count: 3, P50: 10, P95: 20, P99: 30, Max: 40}
+	slow := &Latency{Count: 1, P50: 50, P95: 100, P99: 150, Max: 200}
+	tests := []struct {
+		name string
+		l, o *Latency
+		want *Latency
+	}{
+		{name: "both nil", want: nil},
+		{name: "nil and latency", o: slow, want: slow},
+		{name: "latency and nil", l: fast, want: fast},
+		{name: "weighted by the number of checks", l: fast, o: slow, want: &Latency{Count: 4, P50: 20, P95: 40, P99: 60, Max: 200}},
+		{name: "in either order", l: slow, o: fast, want: &Latency{Count: 4, P50: 20, P95: 40, P99: 60, Max: 200}},
+		{name: "same latency", l: fast, o: fast, want: &Latency{Count: 6, P50: 10, P95: 20, P99: 30, Max: 40}},
+		{
+			name: "without a number of checks",
+			l:    &Latency{P50: 10, P95: 20, P99: 30, Max: 40},
+			o:    &Latency{P50: 30, P95: 40, P99: 50, Max: 60},
+			want: &Latency{P50: 20, P95: 30, P99: 40, Max: 60},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.l.Merge(tt.o)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// merging into a nil latency copies the other one, so that the merged rollups do not share it
+	merged := (*Latency)(nil).Merge(slow)
+	merged.Count++
+	if slow.Count != 1 {
+		t.Error("Merge() into nil returned the latency merged instead of a copy")
+	}
+}
+
+func TestMergeRollups(t *testing.T) {
+	got := mergeRollups(
+		[]Rollup{
+			{Time: "2026-10-19T11:00:00Z", Counts: map[testResult.TestResult]int{testResult.ALL: 2}, Latency: &Latency{Count: 2, P50: 10, P95: 10, P99: 10, Max: 10}},
+			{Time: "2026-10-19T09:00:00Z", Counts: map[testResult.TestResult]int{testResult.NONE: 1}},
+		},
+		[]Rollup{
+			{Time: "2026-10-19T11:00:00Z", Counts: map[testResult.TestResult]int{testResult.ALL: 1, testResult.PART: 1}, Latency: &Latency{Count: 2, P50: 30, P95: 50, P99: 50, Max: 60}},
+			{Time: "2026-10-19T10:00:00Z", Counts: map[testResult.TestResult]int{testResult.ALL: 4}},
+		},
+	)
+	want := []Rollup{
+		{Time: "2026-10-19T09:00:00Z", Counts: map[testResult.TestResult]int{testResult.NONE: 1}},
+		{Time: "2026-10-19T10:00:00Z", Counts: map[testResult.TestResult]int{testResult.ALL: 4}},
+		{Time: "2026-10-19T11:00:00Z", Counts: map[testResult.TestResult]int{testResult.ALL: 3, testResult.PART: 1}, Latency: &Latency{Count: 4, P50: 20, P95: 30, P99: 30, Max: 60}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeRollups() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+	entry := func(at string, status testResult.TestResult, latency int64) Entry {
+		return Entry{Time: at, Online: status, LatencyMs: latency}
+	}
+	entries := []Entry{
+		// older than max_log_days: rolled up by hour, then by day as older than the hourly retention
+		entry("2026-10-10T08:10:00Z", testResult.ALL, 100),
+		entry("2026-10-10T09:40:00Z", testResult.NONE, 0),
+		entry("2026-10-10T23:50:00Z", testResult.ALL, 300),
+		// older than max_log_days: rolled up by hour
+		entry("2026-10-15T10:05:00Z", testResult.ALL, 100),
+		entry("2026-10-15T10:35:00Z", testResult.ALL, 200),
+		entry("2026-10-15T11:05:00Z", testResult.PART, 400),
+		// within max_log_days: kept
+		entry("2026-10-18T12:30:00Z", testResult.ALL, 50),
+	}
+	rollups := &Rollups{Daily: []Rollup{
+		// older than the daily retention: dropped
+		{Time: "2026-08-01T00:00:00Z", Counts: map[testResult.TestResult]int{testResult.ALL: 10}},
+		// merged with the day rolled up from the hourly rollups
+		{Time: "2026-10-10T00:00:00Z", Counts: map[testResult.TestResult]int{testResult.ALL: 1}, Latency: &Latency{Count: 1, P50: 500, P95: 500, P99: 500, Max: 500}},
+	}}
+
+	kept, got := compact(entries, rollups, now, 3, config.Retention{HourlyDays: 7, DailyDays: 30})
+	if want := entries[6:]; !reflect.DeepEqual(kept, want) {
+		t.Errorf("compact() kept %+v, want %+v", kept, want)
+	}
+	want := &Rollups{
+		Hourly: []Rollup{
+			{Time: "2026-10-15T10:00:00Z", Counts: map[testResult.TestResult]int{testResult.ALL: 2}, Latency: &Latency{Count: 2, P50: 100, P95: 200, P99: 200, Max: 200}},
+			{Time: "2026-10-15T11:00:00Z", Counts: map[testResult.TestResult]int{testResult.PART: 1}, Latency: &Latency{Count: 1, P50: 400, P95: 400, P99: 400, Max: 400}},
+		},
+		Daily: []Rollup{
+			// weighted by the number of checks with a latency, the failed check having none
+			{Time: "2026-10-10T00:00:00Z", Counts: map[testResult.TestResult]int{testResult.ALL: 3, testResult.NONE: 1}, Latency: &Latency{Count: 3, P50: 300, P95: 300, P99: 300, Max: 500}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("compact() rollups = %+v, want %+v", got, want)
+	}
+
+	// nothing to roll up
+	kept, got = compact(entries[6:], nil, now, 3, config.Retention{HourlyDays: 7, DailyDays: 30})
+	if len(kept) != 1 || got != nil {
+		t.Errorf("compact() of recent entries = %+v, %+v, want them kept without rollups", kept, got)
+	}
+}
@@ -0,0 +1,241 @@
+package incident
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/pkg/history"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// Statuses of the updates of detected incidents
+const (
+	StatusDetected = "Detected"
+	StatusResolved = "Resolved"
+)
+
+// detectedID returns the stable id of the incident of the service detected at start
+func detectedID(service string, start time.Time) string {
+	sum := sha256.Sum256([]byte(service))
+	return fmt.Sprintf("auto-%s-%s", hex.EncodeToString(sum[:4]), start.UTC().Format("20060102T150405Z"))
+}
+
+// failed reports whether the check failed, cancelled checks, checks in maintenance and unknown results being neither
+// failed nor successful
+func failed(status testResult.TestResult) bool {
+	return status == testResult.NONE || status == testResult.PART
+}
+
+// severityOf returns the severity of a detected incident: major once the service was entirely down, minor otherwise
+func severityOf(status testResult.TestResult) string {
+	if status == testResult.NONE {
+		return Major
+	}
+	return Minor
+}
+
+// failingPorts returns the ports of the service failing at or after start, with the reason of their first failure.
+// The ports are named as in the configuration, their ids being redacted as in the log.
+func failingPorts(cfg *config.Config, name string, svcLog *history.ServiceLog, start time.Time) []PortFailure {
+	names := map[string]string{}
+	for _, svc := range cfg.Services {
+		if svc.Name != name {
+			continue
+		}
+		for _, p := range slices.Concat(svc.Health, svc.API) {
+			names[cfg.Redact(p.Identity())] = cfg.Redact(p.Name)
+		}
+	}
+
+	var ports []PortFailure
+	for _, id := range sortedKeys(svcLog.Ports) {
+		for _, e := range svcLog.Ports[id] {
+			t, err := time.Parse(time.RFC3339, e.Time)
+			if err != nil || t.Before(start) || !failed(e.Online) {
+				continue
+			}
+			reason := e.Error
+			if reason == "" && e.StatusCode != 0 {
+				reason = fmt.Sprintf("HTTP %d", e.StatusCode)
+			}
+			ports = append(ports, PortFailure{ID: id, Name: names[id], Reason: reason})
+			break
+		}
+	}
+	return ports
+}
+
+// detectService opens and closes the incidents of the service from the checks of its history after since,
+// inc being its ongoing incident if any, and returns the incidents it opened
+func detectService(cfg *config.Config, name string, svcLog *history.ServiceLog, inc *Incident, since time.Time) []Incident {
+	var opened []Incident
+	var streak []time.Time
+	severity := Minor // the worst severity over the streak
+	for _, e := range svcLog.ServiceHistory {
+		t, err := time.Parse(time.RFC3339, e.Time)
+		if err != nil || !t.After(since) {
+			continue
+		}
+
+		if !failed(e.Online) {
+			if e.Online == testResult.ALL {
+				streak, severity = nil, Minor
+				if inc != nil {
+					end := t
+					inc.End = &end
+					inc.Updates = append([]Update{{Time: t, Status: StatusResolved, Message: "All checks succeeded again."}}, inc.Updates...)
+					inc = nil
+				}
+			}
+			continue
+		}
+
+		if inc != nil {
+			if severityOf(e.Online) == Major {
+				inc.Severity = Major
+			}
+			continue
+		}
+		streak = append(streak, t)
+		if severityOf(e.Online) == Major {
+			severity = Major
+		}
+		if len(streak) < cfg.IncidentThreshold {
+			continue
+		}
+
+		start := streak[0]
+		opened = append(opened, Incident{
+			ID:          detectedID(name, start),
+			Title:       name + " outage",
+			Description: fmt.Sprintf("Detected after %d consecutive failed checks.", len(streak)),
+			Services:    []string{name},
+			Severity:    severity,
+			Start:       start,
+			Updates:     []Update{{Time: t, Status: StatusDetected, Message: fmt.Sprintf("%d consecutive failed checks.", len(streak))}},
+			Detected:    true,
+			Ports:       failingPorts(cfg, name, svcLog, start),
+		})
+		inc = &opened[len(opened)-1]
+		streak, severity = nil, Minor
+	}
+	return opened
+}
+
+// Detect opens an incident for every service whose last cfg.IncidentThreshold checks failed, and closes the ongoing
+// ones of the services whose checks succeed again or that were removed from the configuration. incidents are the
+// incidents detected by the previous runs; the ones ended more than max_log_days ago are dropped.
+func Detect(cfg *config.Config, logData history.Log, incidents []Incident, now time.Time) []Incident {
+	current := map[string]string{}
+	for _, svc := range cfg.Services {
+		for _, prev := range svc.PreviousNames {
+			current[prev] = svc.Name
+		}
+	}
+
+	// the ongoing incident of every service, and the time its history was examined up to
+	ongoing := map[string]int{}
+	since := map[string]time.Time{}
+	for i := range incidents {
+		inc := &incidents[i]
+		for j, svc := range inc.Services {
+			if cur, ok := current[svc]; ok {
+				inc.Services[j] = cur
+			}
+		}
+		if len(inc.Services) == 0 {
+			continue
+		}
+		svc := inc.Services[0]
+		last := inc.Start
+		if inc.Ongoing() {
+			ongoing[svc] = i
+		} else if inc.End.After(last) {
+			last = *inc.End
+		}
+		if last.After(since[svc]) {
+			since[svc] = last
+		}
+	}
+
+	for _, name := range sortedKeys(ongoing) {
+		// the history of the service was pruned
+		if _, ok := logData[name]; !ok {
+			stop(&incidents[ongoing[name]], now)
+		}
+	}
+	for _, name := range sortedKeys(logData) {
+		svcLog := logData[name]
+		var inc *Incident
+		if i, ok := ongoing[name]; ok {
+			inc = &incidents[i]
+		}
+		if svcLog.Retired == "" {
+			incidents = append(incidents, detectService(cfg, name, svcLog, inc, since[name])...)
+			continue
+		}
+		if retired, err := time.Parse(time.RFC3339, svcLog.Retired); err == nil && inc != nil {
+			stop(inc, retired)
+		}
+	}
+
+	expiry := now.AddDate(0, 0, -cfg.MaxLogDays)
+	incidents = slices.DeleteFunc(incidents, func(inc Incident) bool {
+		return !inc.Ongoing() && inc.End.Before(expiry)
+	})
+	sortIncidents(incidents)
+	return incidents
+}
+
+// stop ends the detected incident at t for a service that is no longer monitored
+func stop(inc *Incident, t time.Time) {
+	if !t.After(inc.Start) {
+		t = inc.Start.Add(time.Second)
+	}
+	inc.End = &t
+	inc.Updates = append([]Update{{Time: t, Status: StatusResolved, Message: "The service is no longer monitored."}}, inc.Updates...)
+}
+
+// Reliability returns the mean time to recovery and the mean time between failures of the service, computed over
+// its detected incidents and the time it was monitored since observed, and the number of incidents they cover
+func Reliability(incidents []Incident, service string, observed, now time.Time) (mttr, mtbf time.Duration, n int) {
+	var downtime, recovery time.Duration
+	resolved := 0
+	for _, inc := range incidents {
+		if !inc.Detected || !inc.Affects(service) {
+			continue
+		}
+		n++
+		if inc.Start.Before(observed) {
+			observed = inc.Start
+		}
+		if inc.Ongoing() {
+			downtime += now.Sub(inc.Start)
+			continue
+		}
+		resolved++
+		recovery += inc.End.Sub(inc.Start)
+		downtime += inc.End.Sub(inc.Start)
+	}
+	if n == 0 {
+		return 0, 0, 0
+	}
+	if resolved > 0 {
+		mttr = recovery / time.Duration(resolved)
+	}
+	return mttr, (now.Sub(observed) - downtime) / time.Duration(n), n
+}
+
+// sortedKeys returns the keys of m in name order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,265 @@
+package incident
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/pkg/history"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+var base = time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+// at returns the time of the i-th check of a series
+func at(i int) time.Time {
+	return base.Add(time.Duration(i) * time.Minute)
+}
+
+// series returns the entries of checks made every minute from base, one per letter of statuses:
+// A, P, N, C, M and U standing for all, part, none, cancelled, maintenance and unknown
+func series(statuses string) []history.Entry {
+	results := map[rune]testResult.TestResult{
+		'A': testResult.ALL,
+		'P': testResult.PART,
+		'N': testResult.NONE,
+		'C': testResult.CANCELLED,
+		'M': testResult.MAINTENANCE,
+		'U': testResult.UNKNOWN,
+	}
+	var entries []history.Entry
+	for i, s := range statuses {
+		entries = append(entries, history.Entry{Time: at(i).Format(time.RFC3339), Online: results[s]})
+	}
+	return entries
+}
+
+func TestDetectService(t *testing.T) {
+	// span defines an incident by the indexes of its first check and of the check ending it, -1 if ongoing
+	type span struct {
+		start, end int
+		severity   string
+	}
+	tests := []struct {
+		name     string
+		statuses string
+		ongoing  *span // the ongoing incident of the service
+		since    int   // the index of the last check examined before, -1 for none
+		want     []span
+		wantInc  *span // the ongoing incident once detected
+	}{
+		{name: "no failure", statuses: "AAAA", since: -1},
+		{name: "below the threshold", statuses: "APPA", since: -1},
+		{name: "interrupted streak", statuses: "PPAPP", since: -1},
+		{name: "at the threshold", statuses: "APPP", since: -1, want: []span{{1, -1, Minor}}},
+		{name: "entirely down", statuses: "NNN", since: -1, want: []span{{0, -1, Major}}},
+		{name: "worst severity of the streak", statuses: "PNP", since: -1, want: []span{{0, -1, Major}}},
+		{name: "closed", statuses: "PPPPA", since: -1, want: []span{{0, 4, Minor}}},
+		{name: "neither failed nor successful", statuses: "PCPMUP", since: -1, want: []span{{0, -1, Minor}}},
+		{name: "not closed by unknown checks", statuses: "PPPUCMA", since: -1, want: []span{{0, 6, Minor}}},
+		{name: "several", statuses: "PPPAANNNA", since: -1, want: []span{{0, 3, Minor}, {5, 8, Major}}},
+		{name: "escalated", statuses: "PPPN", since: -1, want: []span{{0, -1, Major}}},
+		{name: "since cutoff", statuses: "PPPPP", since: 1, want: []span{{2, -1, Minor}}},
+		{name: "since cutoff below the threshold", statuses: "PPPPP", since: 2},
+		{name: "ongoing escalated", statuses: "PN", ongoing: &span{-5, -1, Minor}, since: -1, wantInc: &span{-5, -1, Major}},
+		{name: "ongoing resolved", statuses: "PNA", ongoing: &span{-5, -1, Minor}, since: -1, wantInc: &span{-5, 2, Major}},
+		{name: "ongoing resolved and opened again", statuses: "APPP", ongoing: &span{-5, -1, Minor}, since: -1,
+			want: []span{{1, -1, Minor}}, wantInc: &span{-5, 0, Minor}},
+		{name: "ongoing before since", statuses: "NA", ongoing: &span{-5, -1, Minor}, since: 0, wantInc: &span{-5, 1, Minor}},
+	}
+	cfg := &config.Config{IncidentThreshold: 3}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inc *Incident
+			if tt.ongoing != nil {
+				inc = &Incident{Services: []string{"API"}, Severity: tt.ongoing.severity, Start: at(tt.ongoing.start), Detected: true}
+			}
+			svcLog := &history.ServiceLog{ServiceHistory: series(tt.statuses)}
+			since := base.Add(-time.Hour)
+			if tt.since >= 0 {
+				since = at(tt.since)
+			}
+
+			opened := detectService(cfg, "API", svcLog, inc, since)
+			if len(opened) != len(tt.want) {
+				t.Fatalf("detectService() opened %d incidents, want %d: %+v", len(opened), len(tt.want), opened)
+			}
+			for i, got := range opened {
+				want := tt.want[i]
+				checkSpan(t, got, want.start, want.end, want.severity)
+				if id := detectedID("API", at(want.start)); got.ID != id {
+					t.Errorf("incident %d id = %q, want %q", i, got.ID, id)
+				}
+				if !got.Detected || !reflect.DeepEqual(got.Services, []string{"API"}) {
+					t.Errorf("incident %d = %+v, want detected for API", i, got)
+				}
+			}
+			if tt.wantInc != nil {
+				checkSpan(t, *inc, tt.wantInc.start, tt.wantInc.end, tt.wantInc.severity)
+			}
+		})
+	}
+}
+
+// checkSpan checks the start, end and severity of the incident against the indexes of the checks
+func checkSpan(t *testing.T, inc Incident, start, end int, severity string) {
+	t.Helper()
+	if !inc.Start.Equal(at(start)) {
+		t.Errorf("incident start = %s, want %s", inc.Start, at(start))
+	}
+	if inc.Severity != severity {
+		t.Errorf("incident severity = %s, want %s", inc.Severity, severity)
+	}
+	if end < 0 {
+		if !inc.Ongoing() {
+			t.Errorf("incident ended at %s, want ongoing", inc.End)
+		}
+		return
+	}
+	if inc.Ongoing() || !inc.End.Equal(at(end)) {
+		t.Errorf("incident end = %v, want %s", inc.End, at(end))
+	}
+	if len(inc.Updates) == 0 || inc.Updates[0].Status != StatusResolved {
+		t.Errorf("incident updates = %+v, want a resolved one first", inc.Updates)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	now := at(10)
+	cfg := &config.Config{
+		IncidentThreshold: 3,
+		MaxLogDays:        30,
+		Services: []config.ServiceConfig{{
+			Name:          "API",
+			PreviousNames: []string{"Old API"},
+			Health:        []config.PortConfig{{ID: "health", Name: "Health"}, {ID: "status", Name: "Status"}},
+		}},
+	}
+	logData := history.Log{
+		"API": {
+			ServiceHistory: series("AANNN"),
+			Ports: map[string][]history.Entry{
+				"health": {
+					{Time: at(2).Format(time.RFC3339), Online: testResult.NONE, StatusCode: 503},
+					{Time: at(3).Format(time.RFC3339), Online: testResult.NONE, Error: "timeout"},
+				},
+				"status": {
+					{Time: at(1).Format(time.RFC3339), Online: testResult.NONE, Error: "before the incident"},
+					{Time: at(2).Format(time.RFC3339), Online: testResult.ALL},
+					{Time: at(4).Format(time.RFC3339), Online: testResult.NONE, Error: "refused"},
+				},
+			},
+		},
+		"Retired": {ServiceHistory: series("NNNNN"), Retired: at(3).Format(time.RFC3339)},
+	}
+	expired := base.AddDate(0, 0, -40)
+	incidents := []Incident{
+		{ID: "renamed", Services: []string{"Old API"}, Severity: Minor, Start: at(-20), End: ptr(at(-10)), Detected: true},
+		{ID: "retired", Services: []string{"Retired"}, Severity: Major, Start: at(-5), Detected: true},
+		{ID: "pruned", Services: []string{"Pruned"}, Severity: Minor, Start: at(-5), Detected: true},
+		{ID: "expired", Services: []string{"API"}, Severity: Minor, Start: expired, End: ptr(expired.Add(time.Hour)), Detected: true},
+	}
+
+	got := Detect(cfg, logData, incidents, now)
+	ids := make([]string, len(got))
+	for i, inc := range got {
+		ids[i] = inc.ID
+	}
+	opened := detectedID("API", at(2))
+	if want := []string{opened, "pruned", "retired", "renamed"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("Detect() = %q, want %q", ids, want)
+	}
+
+	if inc := got[0]; inc.Severity != Major || !inc.Ongoing() || !inc.Start.Equal(at(2)) {
+		t.Errorf("opened incident = %+v, want a major one ongoing since %s", inc, at(2))
+	} else if want := []PortFailure{{ID: "health", Name: "Health", Reason: "HTTP 503"}, {ID: "status", Name: "Status", Reason: "refused"}}; !reflect.DeepEqual(inc.Ports, want) {
+		t.Errorf("opened incident ports = %+v, want %+v", inc.Ports, want)
+	}
+	if inc := got[1]; inc.Ongoing() || !inc.End.Equal(now) {
+		t.Errorf("incident of the pruned service ended at %v, want %s", inc.End, now)
+	}
+	if inc := got[2]; inc.Ongoing() || !inc.End.Equal(at(3)) {
+		t.Errorf("incident of the retired service ended at %v, want %s", inc.End, at(3))
+	}
+	if inc := got[3]; !reflect.DeepEqual(inc.Services, []string{"API"}) {
+		t.Errorf("incident of the renamed service affects %q, want it renamed", inc.Services)
+	}
+}
+
+func TestReliability(t *testing.T) {
+	hour := func(h float64) time.Time { return base.Add(time.Duration(h * float64(time.Hour))) }
+	now := hour(100)
+	resolved := func(service string, start, end float64) Incident {
+		return Incident{Services: []string{service}, Start: hour(start), End: ptr(hour(end)), Detected: true}
+	}
+	tests := []struct {
+		name      string
+		incidents []Incident
+		observed  time.Time
+		wantMTTR  time.Duration
+		wantMTBF  time.Duration
+		wantN     int
+	}{
+		{name: "none", observed: base},
+		{
+			name:      "resolved",
+			incidents: []Incident{resolved("API", 10, 11), resolved("API", 20, 23)},
+			observed:  base,
+			wantMTTR:  2 * time.Hour,
+			wantMTBF:  48 * time.Hour, // (100h - 4h) / 2
+			wantN:     2,
+		},
+		{
+			name: "ongoing",
+			incidents: []Incident{
+				resolved("API", 10, 11), resolved("API", 20, 23),
+				{Services: []string{"API"}, Start: hour(98), Detected: true},
+			},
+			observed: base,
+			wantMTTR: 2 * time.Hour,
+			wantMTBF: 31*time.Hour + 20*time.Minute, // (100h - 6h) / 3
+			wantN:    3,
+		},
+		{
+			name:      "only ongoing",
+			incidents: []Incident{{Services: []string{"API"}, Start: hour(90), Detected: true}},
+			observed:  base,
+			wantMTBF:  90 * time.Hour,
+			wantN:     1,
+		},
+		{
+			name: "other incidents",
+			incidents: []Incident{
+				resolved("API", 10, 11),
+				resolved("Web", 20, 30),
+				{Services: []string{"API"}, Start: hour(40), End: ptr(hour(50))},
+				{Start: hour(60), End: ptr(hour(70))},
+			},
+			observed: base,
+			wantMTTR: time.Hour,
+			wantMTBF: 99 * time.Hour,
+			wantN:    1,
+		},
+		{
+			name:      "started before observed",
+			incidents: []Incident{resolved("API", 10, 12)},
+			observed:  hour(50),
+			wantMTTR:  2 * time.Hour,
+			wantMTBF:  88 * time.Hour, // observed from 10h
+			wantN:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mttr, mtbf, n := Reliability(tt.incidents, "API", tt.observed, now)
+			if mttr != tt.wantMTTR || mtbf != tt.wantMTBF || n != tt.wantN {
+				t.Errorf("Reliability() = %s, %s, %d, want %s, %s, %d", mttr, mtbf, n, tt.wantMTTR, tt.wantMTBF, tt.wantN)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
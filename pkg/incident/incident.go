@@ -0,0 +1,223 @@
+// Package incident loads the incident records published on the status page, written as YAML
+// or Markdown files next to the configuration, and publishes them as JSON.
+package incident
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+)
+
+// Severities of an incident, from the least to the most severe
+const (
+	Minor    = "minor"
+	Major    = "major"
+	Critical = "critical"
+)
+
+// Update defines a timestamped update of an incident
+type Update struct {
+	Time    time.Time `yaml:"time" json:"time"`
+	Status  string    `yaml:"status,omitempty" json:"status,omitempty"`
+	Message string    `yaml:"message" json:"message"`
+}
+
+// PortFailure defines a port failing when an incident was detected, with the reason of its first failure
+type PortFailure struct {
+	ID     string `json:"id"`
+	Name   string `json:"name,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Incident defines an incident affecting some services, or all of them if Services is empty.
+// An incident without an end is ongoing.
+type Incident struct {
+	ID          string     `yaml:"id,omitempty" json:"id"`
+	Title       string     `yaml:"title" json:"title"`
+	Description string     `yaml:"description,omitempty" json:"description,omitempty"`
+	Services    []string   `yaml:"services,omitempty" json:"services,omitempty"`
+	Severity    string     `yaml:"severity,omitempty" json:"severity"`
+	Start       time.Time  `yaml:"start" json:"start"`
+	End         *time.Time `yaml:"end,omitempty" json:"end,omitempty"`
+	Updates     []Update   `yaml:"updates,omitempty" json:"updates,omitempty"`
+
+	// Detected is set on the incidents opened from consecutive failed checks, Ports then listing the failing ports
+	Detected bool          `yaml:"-" json:"detected,omitempty"`
+	Ports    []PortFailure `yaml:"-" json:"ports,omitempty"`
+
+	// Source is the file the incident is defined in
+	Source string `yaml:"-" json:"-"`
+}
+
+// Ongoing reports whether the incident has not ended yet
+func (inc *Incident) Ongoing() bool {
+	return inc.End == nil
+}
+
+// Affects reports whether the incident affects the service
+func (inc *Incident) Affects(service string) bool {
+	return len(inc.Services) == 0 || slices.Contains(inc.Services, service)
+}
+
+// ActiveAt reports whether t falls between the start and the end of the incident
+func (inc *Incident) ActiveAt(t time.Time) bool {
+	return !t.Before(inc.Start) && (inc.End == nil || t.Before(*inc.End))
+}
+
+// Load reads the incidents of the YAML and Markdown files in dir, newest first,
+// returning no incidents if the directory does not exist
+func Load(dir string) ([]Incident, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read incidents directory: %w", err)
+	}
+
+	var incidents []Incident
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".md") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read incident file: %w", err)
+		}
+
+		var inc Incident
+		if ext == ".md" {
+			err = parseMarkdown(b, &inc)
+		} else {
+			err = parseYAML(b, &inc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		inc.Source = path
+		if inc.ID == "" {
+			inc.ID = strings.TrimSuffix(e.Name(), ext)
+		}
+		if inc.Severity == "" {
+			inc.Severity = Minor
+		}
+		slices.SortStableFunc(inc.Updates, func(a, b Update) int { return b.Time.Compare(a.Time) })
+		incidents = append(incidents, inc)
+	}
+
+	sortIncidents(incidents)
+	return incidents, nil
+}
+
+// sortIncidents sorts the incidents newest first
+func sortIncidents(incidents []Incident) {
+	slices.SortStableFunc(incidents, func(a, b Incident) int {
+		return cmp.Or(b.Start.Compare(a.Start), cmp.Compare(a.ID, b.ID))
+	})
+}
+
+// Merge returns the incidents of both lists, newest first
+func Merge(a, b []Incident) []Incident {
+	merged := slices.Concat(a, b)
+	sortIncidents(merged)
+	return merged
+}
+
+// Resolve checks the incidents against the configuration, and replaces the previous names
+// of renamed services by their current ones
+func Resolve(incidents []Incident, cfg *config.Config) error {
+	current := map[string]string{}
+	for _, svc := range cfg.Services {
+		current[svc.Name] = svc.Name
+		for _, prev := range svc.PreviousNames {
+			current[prev] = svc.Name
+		}
+	}
+
+	ids := map[string]string{}
+	for i := range incidents {
+		inc := &incidents[i]
+		if prev, ok := ids[inc.ID]; ok {
+			return fmt.Errorf("%s: incident id %q is already used in %s", inc.Source, inc.ID, prev)
+		}
+		ids[inc.ID] = inc.Source
+
+		if err := inc.validate(); err != nil {
+			return fmt.Errorf("%s: %w", inc.Source, err)
+		}
+		for j, name := range inc.Services {
+			svc, ok := current[name]
+			if !ok {
+				return fmt.Errorf("%s: unknown service %q", inc.Source, name)
+			}
+			inc.Services[j] = svc
+		}
+	}
+	return nil
+}
+
+// validate checks the fields of the incident
+func (inc *Incident) validate() error {
+	switch {
+	case inc.Title == "":
+		return errors.New("incident without a title")
+	case inc.Start.IsZero():
+		return errors.New("incident without a start")
+	case inc.End != nil && !inc.End.After(inc.Start):
+		return errors.New("incident must end after it starts")
+	}
+	switch inc.Severity {
+	case Minor, Major, Critical:
+	default:
+		return fmt.Errorf("incident severity must be %s, %s or %s, got %q", Minor, Major, Critical, inc.Severity)
+	}
+	for _, u := range inc.Updates {
+		if u.Time.IsZero() {
+			return errors.New("incident update without a time")
+		}
+	}
+	return nil
+}
+
+// LoadDetected reads the detected incidents from the data file at path,
+// returning no incidents if the file does not exist yet
+func LoadDetected(path string) ([]Incident, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read incidents file: %w", err)
+	}
+	var incidents []Incident
+	if err := json.Unmarshal(b, &incidents); err != nil {
+		return nil, fmt.Errorf("failed to parse incidents file: %w", err)
+	}
+	return incidents, nil
+}
+
+// Save writes the incidents as JSON to the file at path
+func Save(path string, incidents []Incident) error {
+	if incidents == nil {
+		incidents = []Incident{}
+	}
+	b, err := json.MarshalIndent(incidents, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("failed to write incidents file: %w", err)
+	}
+	return nil
+}
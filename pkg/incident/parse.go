@@ -0,0 +1,101 @@
+package incident
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// updateTimeFormats are the formats accepted for the times of the update headings of Markdown incidents,
+// times without a zone being in UTC
+var updateTimeFormats = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04"}
+
+// parseYAML parses an incident written as YAML
+func parseYAML(b []byte, inc *Incident) error {
+	if err := yaml.Unmarshal(b, inc); err != nil {
+		return fmt.Errorf("failed to decode incident: %w", err)
+	}
+	return nil
+}
+
+// parseMarkdown parses an incident written as Markdown. The fields are set in a YAML front matter
+// between two "---" lines, the text before the first heading is the description, and every
+// "## <time> [status]" heading starts an update whose message is the text below it:
+//
+//	---
+//	title: Checkout errors
+//	services: [Payments API]
+//	start: 2026-10-19T10:00:00Z
+//	---
+//	Some payments fail with a timeout.
+//
+//	## 2026-10-19T10:20:00Z Identified
+//	A database failover is in progress.
+func parseMarkdown(b []byte, inc *Incident) error {
+	text := strings.ReplaceAll(string(b), "\r\n", "\n")
+	rest, ok := strings.CutPrefix(text, "---\n")
+	if !ok {
+		return errors.New("markdown incident must start with a --- front matter")
+	}
+	front, body, ok := strings.Cut(rest, "\n---\n")
+	if !ok {
+		if front, ok = strings.CutSuffix(rest, "\n---"); !ok {
+			return errors.New("markdown incident front matter is not closed with ---")
+		}
+	}
+	if err := parseYAML([]byte(front), inc); err != nil {
+		return err
+	}
+
+	var description bytes.Buffer
+	var current *Update
+	flush := func() {
+		if current != nil {
+			current.Message = strings.TrimSpace(current.Message)
+			inc.Updates = append(inc.Updates, *current)
+		}
+	}
+	for _, line := range strings.Split(body, "\n") {
+		heading, isHeading := strings.CutPrefix(line, "## ")
+		if !isHeading {
+			if current != nil {
+				current.Message += line + "\n"
+			} else {
+				description.WriteString(line + "\n")
+			}
+			continue
+		}
+
+		flush()
+		u, err := parseUpdateHeading(heading)
+		if err != nil {
+			return err
+		}
+		current = &u
+	}
+	flush()
+
+	if inc.Description == "" {
+		inc.Description = strings.TrimSpace(description.String())
+	}
+	return nil
+}
+
+// parseUpdateHeading parses the heading "<time> [status]" of an update
+func parseUpdateHeading(heading string) (Update, error) {
+	fields := strings.Fields(heading)
+	for n := 1; n <= min(2, len(fields)); n++ {
+		// the time takes one field, or two if the date and the time are separated by a space
+		prefix := strings.Join(fields[:n], " ")
+		for _, layout := range updateTimeFormats {
+			if t, err := time.Parse(layout, prefix); err == nil {
+				return Update{Time: t, Status: strings.Join(fields[n:], " ")}, nil
+			}
+		}
+	}
+	return Update{}, fmt.Errorf("update heading %q must start with a time such as 2026-10-19T10:20:00Z", heading)
+}
@@ -0,0 +1,143 @@
+package ponghub
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/pkg/history"
+)
+
+// usage describes the commands of the ponghub binary
+const usage = `Usage: ponghub [flags] [command]
+
+Commands:
+  run                 check the services, update the log and generate the report (default)
+  config resolved     print the configuration with the effective settings of every port
+  history prune       drop the history of the services and ports missing from the configuration
+
+Selecting services:
+  -only tag=prod -only tag=staging   services tagged prod or staging
+  -only tag=prod -group payments     production services of the payments group
+
+Flags:
+`
+
+// parseArgs parses the flags, which may appear before or after the command words, into opts and dryRun,
+// and returns the command words
+func parseArgs(args []string, opts *Options, dryRun *bool) ([]string, error) {
+	fs := flag.NewFlagSet("ponghub", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.ConfigPath, "config", opts.ConfigPath, "path to the configuration file")
+	fs.StringVar(&opts.LogPath, "log", opts.LogPath, "path to the log file")
+	fs.StringVar(&opts.TemplatePath, "template", opts.TemplatePath, "path to the report template, unless reports are configured")
+	fs.StringVar(&opts.ReportPath, "report", opts.ReportPath, "path to the generated report, unless reports are configured")
+	fs.StringVar(&opts.IncidentLogPath, "incident-log", opts.IncidentLogPath, "path to the data file of the detected incidents")
+	fs.BoolVar(dryRun, "dry-run", false, "only print what history prune would drop")
+	fs.Func("only", "only check the services matching `key=value`, where key is tag, group or name (repeatable)", opts.Selector.Add)
+	fs.Func("group", "only check the services of the `group` (repeatable)", func(group string) error {
+		return opts.Selector.Add("group=" + group)
+	})
+
+	var words []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return words, nil
+		}
+		words = append(words, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// printResolvedConfig prints the configuration after the settings of every port have been resolved,
+// with the secrets redacted
+func printResolvedConfig(opts *Options) error {
+	cfg, err := config.Load(opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+	if cfg, err = cfg.Select(opts.Selector); err != nil {
+		return err
+	}
+	return config.Encode(os.Stdout, cfg)
+}
+
+// pruneHistory drops the history of the services and ports missing from the configuration,
+// after moving the history of renamed services, and saves the log unless dryRun is set
+func pruneHistory(opts *Options, dryRun bool) error {
+	cfg, err := config.Load(opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+	logData, err := history.Load(opts.LogPath)
+	if err != nil {
+		return err
+	}
+
+	dropped := logData.Prune(cfg)
+	for _, d := range dropped {
+		if dryRun {
+			log.Println("Would drop", d)
+		} else {
+			log.Println("Dropped", d)
+		}
+	}
+	if len(dropped) == 0 {
+		log.Println("Nothing to prune in", opts.LogPath)
+	}
+	if dryRun {
+		return nil
+	}
+	return logData.Save(opts.LogPath)
+}
+
+// Main runs the command given on the command line with the default options, and exits on error.
+// During a run, the first SIGINT or SIGTERM cancels the checks in flight, a second one exits immediately.
+func Main() {
+	opts := DefaultOptions()
+	var dryRun bool
+	words, err := parseArgs(os.Args[1:], opts, &dryRun)
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	switch strings.Join(words, " ") {
+	case "", "run":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			stop()
+		}()
+
+		if err := Run(ctx, opts); err != nil {
+			log.Fatalln(err)
+		}
+	case "history prune":
+		if err := pruneHistory(opts, dryRun); err != nil {
+			log.Fatalln(err)
+		}
+	case "config resolved":
+		if err := printResolvedConfig(opts); err != nil {
+			log.Fatalln(err)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", strings.Join(words, " "), usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,156 @@
+// Package ponghub runs the whole PongHub pipeline: loading the configuration, checking the services,
+// updating the log and generating the report. Custom binaries register their checkers with the
+// checker package and then call Main, while other Go programs can use Run or the underlying
+// config, checker, history and report packages directly.
+package ponghub
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/checker"
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/pkg/history"
+	"github.com/wcy-dt/ponghub/pkg/incident"
+	"github.com/wcy-dt/ponghub/pkg/report"
+	"github.com/wcy-dt/ponghub/protos/defaultConfig"
+)
+
+// Options defines the files and dependencies used by a run
+type Options struct {
+	ConfigPath   string
+	LogPath      string
+	TemplatePath string
+	ReportPath   string
+
+	// IncidentLogPath is the data file of the incidents detected from consecutive failed checks
+	IncidentLogPath string
+
+	// Selector limits the run to the services it matches; all services are checked if it is empty
+	Selector config.Selector
+
+	// Checker holds the HTTP client and logger used by the checks
+	Checker checker.Options
+}
+
+// redactingWriter writes to w with the secrets of the configuration redacted
+type redactingWriter struct {
+	w      io.Writer
+	redact func(string) string
+}
+
+// Write writes p to the underlying writer with the secrets redacted
+func (rw *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(rw.w, rw.redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// DefaultOptions returns the options with the default file paths
+func DefaultOptions() *Options {
+	return &Options{
+		ConfigPath:      defaultConfig.GetConfigPath(),
+		LogPath:         defaultConfig.GetLogPath(),
+		TemplatePath:    defaultConfig.GetTemplatePath(),
+		ReportPath:      defaultConfig.GetReportPath(),
+		IncidentLogPath: defaultConfig.GetIncidentLogPath(),
+		Selector:        config.Selector{},
+	}
+}
+
+// reportTargets returns the report targets of the configuration, or a single report
+// at the report and template paths of the options if none is configured
+func reportTargets(cfg *config.Config, opts *Options) []config.ReportTarget {
+	if len(cfg.Reports) > 0 {
+		return cfg.Reports
+	}
+	return []config.ReportTarget{{
+		Output:       opts.ReportPath,
+		Template:     opts.TemplatePath,
+		ReportConfig: cfg.Report,
+	}}
+}
+
+// Run loads the configuration and the incidents, checks the services, updates the log,
+// detects incidents from it and generates the reports
+func Run(ctx context.Context, opts *Options) error {
+	// load the configuration
+	cfg, err := config.Load(opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	// load the incident records, which name services as the configuration does
+	incidents, err := incident.Load(cfg.Incidents)
+	if err != nil {
+		return fmt.Errorf("error loading incidents: %w", err)
+	}
+	if err := incident.Resolve(incidents, cfg); err != nil {
+		return fmt.Errorf("error loading incidents: %w", err)
+	}
+
+	// only check the selected services, the others keep their history and stay in the report
+	selected, err := cfg.Select(opts.Selector)
+	if err != nil {
+		return err
+	}
+
+	// bound the whole run if a run timeout is configured
+	if cfg.RunTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, time.Duration(cfg.RunTimeout)*time.Second,
+			fmt.Errorf("run timeout of %ds exceeded", cfg.RunTimeout))
+		defer cancel()
+	}
+
+	// keep the secret values out of the logs
+	checkOpts := opts.Checker
+	logger := checkOpts.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	checkOpts.Logger = log.New(&redactingWriter{w: logger.Writer(), redact: cfg.Redact}, logger.Prefix(), logger.Flags())
+
+	// check services based on the configuration,
+	// an interrupted run still records the ports checked so far and marks the others as cancelled
+	results, checkErr := checker.CheckServices(ctx, selected, &checkOpts)
+	for i := range results {
+		results[i].Redact(cfg.Redact)
+	}
+	if err := history.OutputResults(opts.LogPath, cfg, results); err != nil {
+		return fmt.Errorf("error outputting results: %w", err)
+	}
+
+	// open and close the incidents detected from the updated history, kept across runs like the history
+	logData, err := history.Load(opts.LogPath)
+	if err != nil {
+		return fmt.Errorf("error outputting results: %w", err)
+	}
+	detected, err := incident.LoadDetected(opts.IncidentLogPath)
+	if err != nil {
+		return fmt.Errorf("error loading incidents: %w", err)
+	}
+	detected = incident.Detect(cfg, logData, detected, time.Now())
+	if err := incident.Save(opts.IncidentLogPath, detected); err != nil {
+		return fmt.Errorf("error outputting incidents: %w", err)
+	}
+	incidents = incident.Merge(incidents, detected)
+
+	// generate the reports based on the results
+	targets := reportTargets(cfg, opts)
+	if err := report.GenerateReport(cfg, opts.LogPath, incidents, targets); err != nil {
+		return fmt.Errorf("error generating report: %w", err)
+	}
+	for _, t := range targets {
+		logger.Println("Report generated at", t.Output)
+	}
+
+	if checkErr != nil {
+		return fmt.Errorf("run cancelled: %w", checkErr)
+	}
+	return nil
+}
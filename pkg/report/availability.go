@@ -0,0 +1,192 @@
+package report
+
+import (
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/pkg/history"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// availabilityWindows are the periods over which the availability of every service and port is shown
+var availabilityWindows = []struct {
+	label string
+	span  time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+	{"90d", 90 * 24 * time.Hour},
+}
+
+// burnRateSpan is the period over which the burn rate of an error budget is measured
+const burnRateSpan = 24 * time.Hour
+
+// WindowAvailability defines the availability over a period, Known being false if no state counts in it,
+// and the fraction of the period without data
+type WindowAvailability struct {
+	Label        string
+	Availability float64
+	Known        bool
+	Unknown      float64
+}
+
+// SLOResult defines the state of the service level objective of a service. Budget is the fraction of the
+// error budget left over the window, negative once exceeded, and BurnRate how fast it was spent over the
+// last day, 1 spending it exactly by the end of the window.
+type SLOResult struct {
+	Target       float64
+	WindowDays   int
+	Availability float64
+	Known        bool
+	Budget       float64
+	BurnRate     float64
+	BurnKnown    bool
+}
+
+// score returns how much a status counts as available, or false if it does not count in the availability
+func score(status testResult.TestResult, partCountsAs string) (float64, bool) {
+	switch status {
+	case testResult.ALL:
+		return 1, true
+	case testResult.NONE:
+		return 0, true
+	case testResult.PART:
+		switch partCountsAs {
+		case config.PartUp:
+			return 1, true
+		case config.PartHalf:
+			return 0.5, true
+		default:
+			return 0, true
+		}
+	default:
+		return 0, false
+	}
+}
+
+// stateTimes defines how long a service or port was up, down and in maintenance over a period
+type stateTimes struct {
+	up, down, maintenance time.Duration
+}
+
+// add counts the duration d spent in the status, cancelled checks and unknown results saying nothing
+// about the state and maintenance being planned downtime left out of the availability
+func (st *stateTimes) add(status testResult.TestResult, d time.Duration, r *config.ReportConfig) {
+	if s, ok := score(status, r.PartCountsAs); ok {
+		up := time.Duration(float64(d) * s)
+		st.up += up
+		st.down += d - up
+	} else if status == testResult.MAINTENANCE {
+		st.maintenance += d
+	}
+}
+
+// clip returns the part of the period from start to end that falls between since and until
+func clip(start, end, since, until time.Time) time.Duration {
+	if start.Before(since) {
+		start = since
+	}
+	if end.After(until) {
+		end = until
+	}
+	return max(0, end.Sub(start))
+}
+
+// measureRollups sums the time spent in each state during the rollups of the given span, from since to until.
+// As their checks are no longer timed, every check is assumed to last unknownAfter, the states sharing the
+// covered part of the period in proportion to their checks.
+func (st *stateTimes) measureRollups(rollups []history.Rollup, span time.Duration, since, until time.Time, r *config.ReportConfig) {
+	for _, rollup := range rollups {
+		start, err := time.Parse(time.RFC3339, rollup.Time)
+		if err != nil {
+			continue
+		}
+		d := clip(start, start.Add(span), since, until)
+		total := rollup.Total()
+		if d == 0 || total == 0 {
+			continue
+		}
+		covered := min(float64(d), float64(d)*float64(total)*float64(r.UnknownAfter)/float64(span))
+		for status, n := range rollup.Counts {
+			st.add(status, time.Duration(covered*float64(n)/float64(total)), r)
+		}
+	}
+}
+
+// measureEntries sums the time the entries spent in each state from since to until. Every checked state lasts
+// until the next check, but at most unknownAfter, the rest of a longer gap being unknown.
+func (st *stateTimes) measureEntries(entries []history.Entry, since, until time.Time, r *config.ReportConfig) {
+	type check struct {
+		time   time.Time
+		status testResult.TestResult
+	}
+	var checks []check
+	for _, e := range entries {
+		if t, err := time.Parse(time.RFC3339, e.Time); err == nil && t.Before(until) {
+			checks = append(checks, check{t, e.Online})
+		}
+	}
+
+	for i, c := range checks {
+		end := until
+		if i+1 < len(checks) {
+			end = checks[i+1].time
+		}
+		if limit := c.time.Add(r.UnknownAfter); limit.Before(end) {
+			end = limit
+		}
+		st.add(c.status, clip(c.time, end, since, until), r)
+	}
+}
+
+// availability returns the time-weighted availability of the series from since to until, reading the daily
+// and hourly rollups before the entries, the fraction of the period without data, and false if no state counts
+// in the period
+func availability(s history.Series, since, until time.Time, r *config.ReportConfig) (float64, float64, bool) {
+	var st stateTimes
+	st.measureRollups(s.Daily, 24*time.Hour, since, until, r)
+	st.measureRollups(s.Hourly, time.Hour, since, until, r)
+	st.measureEntries(s.Entries, since, until, r)
+
+	var unknown float64
+	if span := until.Sub(since); span > 0 {
+		unknown = float64(span-st.up-st.down-st.maintenance) / float64(span)
+	}
+	if st.up+st.down == 0 {
+		return 0, unknown, false
+	}
+	return float64(st.up) / float64(st.up+st.down), unknown, true
+}
+
+// overallAvailability returns the time-weighted availability of the series from its start to now
+func overallAvailability(s history.Series, now time.Time, r *config.ReportConfig) float64 {
+	a, _, _ := availability(s, s.Start(), now, r)
+	return a
+}
+
+// windowAvailability returns the availability of the series over every availability window before now
+func windowAvailability(s history.Series, now time.Time, r *config.ReportConfig) []WindowAvailability {
+	var windows []WindowAvailability
+	for _, w := range availabilityWindows {
+		a, unknown, ok := availability(s, now.Add(-w.span), now, r)
+		windows = append(windows, WindowAvailability{Label: w.label, Availability: a, Known: ok, Unknown: unknown})
+	}
+	return windows
+}
+
+// newSLOResult returns the state of the service level objective for the series of a service before now
+func newSLOResult(slo *config.SLOConfig, s history.Series, now time.Time, r *config.ReportConfig) *SLOResult {
+	result := &SLOResult{Target: slo.Target, WindowDays: slo.WindowDays}
+	allowed := 1 - slo.Target/100
+
+	result.Availability, _, result.Known = availability(s, now.AddDate(0, 0, -slo.WindowDays), now, r)
+	if result.Known {
+		result.Budget = 1 - (1-result.Availability)/allowed
+	}
+	var recent float64
+	if recent, _, result.BurnKnown = availability(s, now.Add(-burnRateSpan), now, r); result.BurnKnown {
+		result.BurnRate = (1 - recent) / allowed
+	}
+	return result
+}
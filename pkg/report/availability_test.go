@@ -0,0 +1,171 @@
+package report
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/pkg/history"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+func TestAvailability(t *testing.T) {
+	// a period of four hours across midnight
+	since := time.Date(2026, 10, 18, 22, 0, 0, 0, time.UTC)
+	until := since.Add(4 * time.Hour)
+	at := func(minutes int) string {
+		return since.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339)
+	}
+	entries := func(checks ...any) []history.Entry {
+		var list []history.Entry
+		for i := 0; i < len(checks); i += 2 {
+			list = append(list, history.Entry{Time: at(checks[i].(int)), Online: checks[i+1].(testResult.TestResult)})
+		}
+		return list
+	}
+	counts := func(up, down int) map[testResult.TestResult]int {
+		return map[testResult.TestResult]int{testResult.ALL: up, testResult.NONE: down}
+	}
+
+	tests := []struct {
+		name        string
+		series      history.Series
+		part        string
+		want        float64
+		wantUnknown float64
+		wantKnown   bool
+	}{
+		{
+			name:      "up across midnight",
+			series:    history.Series{Entries: entries(0, testResult.ALL, 60, testResult.ALL, 120, testResult.ALL, 180, testResult.ALL)},
+			want:      1,
+			wantKnown: true,
+		},
+		{
+			name:        "down after midnight, with gaps longer than unknown_after",
+			series:      history.Series{Entries: entries(0, testResult.ALL, 120, testResult.NONE)},
+			want:        0.5,
+			wantUnknown: 0.5,
+			wantKnown:   true,
+		},
+		{
+			name:      "weighted by time, not by checks",
+			series:    history.Series{Entries: entries(0, testResult.ALL, 10, testResult.NONE, 20, testResult.NONE, 30, testResult.ALL, 90, testResult.ALL, 150, testResult.ALL, 210, testResult.ALL)},
+			want:      220.0 / 240,
+			wantKnown: true,
+		},
+		{
+			name:        "state checked before the period",
+			series:      history.Series{Entries: entries(-30, testResult.ALL, 30, testResult.NONE)},
+			want:        1.0 / 3,
+			wantUnknown: 150.0 / 240,
+			wantKnown:   true,
+		},
+		{
+			name:        "checks from the end of the period on",
+			series:      history.Series{Entries: entries(0, testResult.ALL, 240, testResult.NONE, 250, testResult.NONE)},
+			want:        1,
+			wantUnknown: 0.75,
+			wantKnown:   true,
+		},
+		{
+			name:      "maintenance left out",
+			series:    history.Series{Entries: entries(0, testResult.ALL, 60, testResult.MAINTENANCE, 120, testResult.NONE, 180, testResult.ALL)},
+			want:      2.0 / 3,
+			wantKnown: true,
+		},
+		{
+			name:        "cancelled checks say nothing",
+			series:      history.Series{Entries: entries(0, testResult.ALL, 60, testResult.CANCELLED, 120, testResult.ALL, 180, testResult.ALL)},
+			want:        1,
+			wantUnknown: 0.25,
+			wantKnown:   true,
+		},
+		{
+			name:      "partly available as down",
+			series:    history.Series{Entries: entries(0, testResult.PART, 60, testResult.ALL, 120, testResult.ALL, 180, testResult.ALL)},
+			part:      config.PartDown,
+			want:      0.75,
+			wantKnown: true,
+		},
+		{
+			name:      "partly available as half",
+			series:    history.Series{Entries: entries(0, testResult.PART, 60, testResult.ALL, 120, testResult.ALL, 180, testResult.ALL)},
+			part:      config.PartHalf,
+			want:      0.875,
+			wantKnown: true,
+		},
+		{
+			name:      "partly available as up",
+			series:    history.Series{Entries: entries(0, testResult.PART, 60, testResult.ALL, 120, testResult.ALL, 180, testResult.ALL)},
+			part:      config.PartUp,
+			want:      1,
+			wantKnown: true,
+		},
+		{
+			name:        "no data",
+			wantUnknown: 1,
+		},
+		{
+			name:   "only maintenance",
+			series: history.Series{Entries: entries(0, testResult.MAINTENANCE, 60, testResult.MAINTENANCE, 120, testResult.MAINTENANCE, 180, testResult.MAINTENANCE)},
+		},
+		{
+			name: "hourly rollups before the entries",
+			series: history.Series{
+				Hourly: []history.Rollup{
+					{Time: at(0), Counts: counts(3, 1)},
+					{Time: at(60), Counts: counts(2, 0)},
+				},
+				Entries: entries(120, testResult.ALL, 180, testResult.ALL),
+			},
+			want:      225.0 / 240,
+			wantKnown: true,
+		},
+		{
+			name: "sparse hourly rollup",
+			series: history.Series{Hourly: []history.Rollup{
+				{Time: at(0), Counts: map[testResult.TestResult]int{testResult.ALL: 0}},
+				{Time: at(60), Counts: counts(0, 1)},
+			}},
+			want:        0,
+			wantUnknown: 0.75,
+			wantKnown:   true,
+		},
+		{
+			name: "daily rollup clipped to the period",
+			series: history.Series{Daily: []history.Rollup{
+				{Time: "2026-10-18T00:00:00Z", Counts: counts(6, 6)},
+			}},
+			want:        0.5,
+			wantUnknown: 0.75,
+			wantKnown:   true,
+		},
+		{
+			name: "times with an offset",
+			series: history.Series{Entries: []history.Entry{
+				{Time: "2026-10-19T00:00:00+02:00", Online: testResult.NONE},
+				{Time: "2026-10-19T01:00:00+02:00", Online: testResult.ALL},
+			}},
+			want:        0.5,
+			wantUnknown: 0.5,
+			wantKnown:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &config.ReportConfig{PartCountsAs: tt.part, UnknownAfter: time.Hour}
+			got, unknown, known := availability(tt.series, since, until, r)
+			if known != tt.wantKnown {
+				t.Fatalf("availability() known = %v, want %v", known, tt.wantKnown)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("availability() = %v, want %v", got, tt.want)
+			}
+			if math.Abs(unknown-tt.wantUnknown) > 1e-9 {
+				t.Errorf("availability() unknown = %v, want %v", unknown, tt.wantUnknown)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// the colors of the badges, as those of shields.io
+const (
+	badgeGreen  = "#4c1"
+	badgeYellow = "#dfb317"
+	badgeRed    = "#e05d44"
+	badgeBlue   = "#007ec6"
+	badgeGray   = "#9f9f9f"
+	badgeLabel  = "#555"
+)
+
+// badge defines a badge of a service or group: a label, such as its name, and a message on the color
+type badge struct {
+	label, message, color string
+}
+
+// badgeSubject defines a service or group a badge is made for
+type badgeSubject struct {
+	name         string
+	status       string // the status text, such as operational
+	statusColor  string // the color of the status, empty if it does not affect the color
+	availability float64
+	windows      []WindowAvailability
+}
+
+// stateOf returns the text of a status and its color, empty if it does not affect the color of a badge
+func stateOf(status string) (string, string) {
+	switch testResult.ParseTestResult(status) {
+	case testResult.ALL:
+		return "operational", badgeGreen
+	case testResult.PART:
+		return "degraded", badgeYellow
+	case testResult.NONE:
+		return "down", badgeRed
+	case testResult.MAINTENANCE:
+		return "maintenance", badgeBlue
+	case testResult.CANCELLED:
+		return "cancelled", ""
+	default:
+		return "unknown", ""
+	}
+}
+
+// availabilityColor returns the color of an availability, with the thresholds of the template:
+// red below 95%, yellow below 100% and green at 100%
+func availabilityColor(a float64) string {
+	switch rate := a * 100; {
+	case rate < 95:
+		return badgeRed
+	case rate < 100:
+		return badgeYellow
+	default:
+		return badgeGreen
+	}
+}
+
+// colorRank orders the colors of the badges from the worst
+func colorRank(color string) int {
+	switch color {
+	case badgeRed:
+		return 0
+	case badgeYellow:
+		return 1
+	case badgeBlue:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// badges returns the badges of the subject by file name: its status with its availability over the history,
+// colored by the worse of the two, and its availability over every availability window
+func (s *badgeSubject) badges() map[string]badge {
+	color := availabilityColor(s.availability)
+	if s.statusColor != "" && colorRank(s.statusColor) < colorRank(color) {
+		color = s.statusColor
+	}
+	if s.status == "retired" {
+		color = badgeGray
+	}
+	badges := map[string]badge{
+		"status.svg": {label: s.name, message: fmt.Sprintf("%s %s", s.status, formatRate(s.availability)), color: color},
+	}
+	for _, w := range s.windows {
+		b := badge{label: s.name + " " + w.Label, message: "no data", color: badgeGray}
+		if w.Known {
+			b.message, b.color = formatRate(w.Availability), availabilityColor(w.Availability)
+		}
+		badges[w.Label+".svg"] = b
+	}
+	return badges
+}
+
+// formatRate returns an availability as a percentage, without the decimals it does not need
+func formatRate(a float64) string {
+	s := fmt.Sprintf("%.2f", a*100)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	return s + "%"
+}
+
+// textWidth estimates the width of the text in the 11px Verdana of the badges
+func textWidth(s string) int {
+	width := 0.0
+	for _, r := range s {
+		switch {
+		case r > unicode.MaxLatin1:
+			width += 11
+		case unicode.IsUpper(r) || r == 'm' || r == 'w' || r == '%':
+			width += 8.5
+		case r == 'i' || r == 'l' || r == 'j' || r == '.' || r == ' ':
+			width += 3.5
+		default:
+			width += 6.8
+		}
+	}
+	return int(width + 0.5)
+}
+
+// svg renders the badge in the flat style of shields.io
+func (b *badge) svg() string {
+	label, message := template.HTMLEscapeString(b.label), template.HTMLEscapeString(b.message)
+	lw, mw := textWidth(b.label)+10, textWidth(b.message)+10
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, lw+mw, label, message)
+	fmt.Fprintf(&sb, `<title>%s: %s</title>`, label, message)
+	sb.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&sb, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, lw+mw)
+	fmt.Fprintf(&sb, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="%s"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`,
+		lw, badgeLabel, lw, mw, b.color, lw+mw)
+	sb.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	for _, text := range []struct {
+		x    float64
+		text string
+	}{{float64(lw) / 2, label}, {float64(lw) + float64(mw)/2, message}} {
+		fmt.Fprintf(&sb, `<text x="%.1f" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%.1f" y="14">%s</text>`, text.x, text.text, text.x, text.text)
+	}
+	sb.WriteString(`</g></svg>`)
+	return sb.String()
+}
+
+// slug returns the name as a file name: lower case letters and digits separated by dashes
+func slug(name string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if sb.Len() == 0 {
+		return "unnamed"
+	}
+	return sb.String()
+}
+
+// badgeDir returns the directory of the badges of the report written to output, named after it so that
+// the reports written to the same directory do not overwrite the badges of one another
+func badgeDir(output string) string {
+	name := strings.TrimSuffix(filepath.Base(output), filepath.Ext(output))
+	return filepath.Join(filepath.Dir(output), "badges", name)
+}
+
+// groupSubject returns the group as the subject of badges, its availability being the mean of those of its services
+func groupSubject(g *GroupResult) badgeSubject {
+	s := badgeSubject{name: g.Name}
+	s.status, s.statusColor = stateOf(g.Status)
+	for _, w := range availabilityWindows {
+		s.windows = append(s.windows, WindowAvailability{Label: w.label})
+	}
+	known := make([]int, len(s.windows))
+	for _, svc := range g.Services {
+		s.availability += svc.Availability / float64(len(g.Services))
+		for i, w := range svc.Windows {
+			if w.Known {
+				s.windows[i].Availability += w.Availability
+				s.windows[i].Known = true
+				known[i]++
+			}
+		}
+	}
+	for i := range s.windows {
+		if known[i] > 0 {
+			s.windows[i].Availability /= float64(known[i])
+		}
+	}
+	return s
+}
+
+// writeBadges writes the badges of the services and groups into the badge directory of the report written
+// to output, replacing those of the previous run: the badges of a service are in services/<name>/ and
+// those of a group in groups/<name>/
+func writeBadges(output string, results []ServiceResult) error {
+	dir := badgeDir(output)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove old badges: %w", err)
+	}
+
+	subjects := map[string][]badgeSubject{}
+	for i := range results {
+		svc := &results[i]
+		s := badgeSubject{name: svc.Name, availability: svc.Availability, windows: svc.Windows}
+		s.status, s.statusColor = stateOf(svc.lastStatus())
+		if svc.Retired {
+			s.status, s.statusColor = "retired", ""
+		}
+		subjects["services"] = append(subjects["services"], s)
+	}
+	for _, g := range groupResults(results) {
+		if g.Name != "" && !g.Archived {
+			subjects["groups"] = append(subjects["groups"], groupSubject(&g))
+		}
+	}
+
+	for kind, list := range subjects {
+		shared := map[string]int{}
+		for _, s := range list {
+			shared[slug(s.name)]++
+		}
+		for _, s := range list {
+			name := slug(s.name)
+			// names differing only in case or punctuation are told apart by a hash of the name,
+			// so that their paths do not depend on the order of the services
+			if shared[name] > 1 {
+				sum := sha256.Sum256([]byte(s.name))
+				name += "-" + hex.EncodeToString(sum[:3])
+			}
+			path := filepath.Join(dir, kind, name)
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return fmt.Errorf("failed to create badge directory: %w", err)
+			}
+			for file, b := range s.badges() {
+				if err := os.WriteFile(filepath.Join(path, file), []byte(b.svg()), 0644); err != nil {
+					return fmt.Errorf("failed to write badge: %w", err)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"API", "api"},
+		{"api!", "api"},
+		{"Payments API", "payments-api"},
+		{"  Payments -- API  ", "payments-api"},
+		{"v2.1 gateway", "v2-1-gateway"},
+		{"Café", "café"},
+		{"日本語 サービス", "日本語-サービス"},
+		{"", "unnamed"},
+		{"!!!", "unnamed"},
+		{" - ", "unnamed"},
+	}
+	for _, tt := range tests {
+		if got := slug(tt.name); got != tt.want {
+			t.Errorf("slug(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// badgeFiles returns the badge files written for the report at output, relative to its badge directory
+func badgeFiles(t *testing.T, output string) []string {
+	t.Helper()
+	dir := badgeDir(output)
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(files)
+	return files
+}
+
+func TestWriteBadges(t *testing.T) {
+	hashed := func(name string) string {
+		sum := sha256.Sum256([]byte(name))
+		return slug(name) + "-" + hex.EncodeToString(sum[:3])
+	}
+	// badges returns the badge files of the services and groups in the given directories
+	badges := func(dirs ...string) []string {
+		var files []string
+		for _, dir := range dirs {
+			files = append(files, dir+"/status.svg")
+			for _, w := range availabilityWindows {
+				files = append(files, dir+"/"+w.label+".svg")
+			}
+		}
+		slices.Sort(files)
+		return files
+	}
+	var windows []WindowAvailability
+	for _, w := range availabilityWindows {
+		windows = append(windows, WindowAvailability{Label: w.label})
+	}
+	results := []ServiceResult{
+		{Name: "API", Group: "Core", Availability: 1, Windows: windows},
+		{Name: "api!", Group: "core", Availability: 0.9, Windows: windows},
+		{Name: "Web", Group: "Core", Availability: 1, Windows: windows},
+		{Name: "!!!", Availability: 1, Windows: windows},
+	}
+	want := badges(
+		"services/"+hashed("API"),
+		"services/"+hashed("api!"),
+		"services/web",
+		"services/unnamed",
+		"groups/"+hashed("Core"),
+		"groups/"+hashed("core"),
+	)
+
+	// the paths do not depend on the order of the services
+	reversed := slices.Clone(results)
+	slices.Reverse(reversed)
+	output := filepath.Join(t.TempDir(), "index.html")
+	for _, order := range [][]ServiceResult{results, reversed} {
+		if err := writeBadges(output, order); err != nil {
+			t.Fatal(err)
+		}
+		if got := badgeFiles(t, output); !reflect.DeepEqual(got, want) {
+			t.Fatalf("writeBadges() wrote %q, want %q", got, want)
+		}
+	}
+
+	// the badges of a service are those of its own name
+	b, err := os.ReadFile(filepath.Join(badgeDir(output), "services", hashed("api!"), "status.svg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "<title>api!: unknown 90%</title>") {
+		t.Errorf("badge of api! = %s", b)
+	}
+
+	// without the collision, the names are no longer hashed and the badges of the previous run are removed
+	if err := writeBadges(output, results[1:2]); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := badgeFiles(t, output), badges("services/api", "groups/core"); !reflect.DeepEqual(got, want) {
+		t.Errorf("writeBadges() wrote %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,150 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// the sizes of the latency charts in SVG user units; the detailed chart leaves margins for its labels
+const (
+	sparklineWidth  = 120
+	sparklineHeight = 24
+	chartWidth      = 600
+	chartHeight     = 140
+	chartLeft       = 48
+	chartTop        = 8
+	chartBottom     = 118
+)
+
+// chartArea maps the buckets of a timeline and their latencies to the points of a chart
+type chartArea struct {
+	left, top, width, height float64
+	buckets                  int
+	ceiling                  int64 // the latency at the top of the chart
+}
+
+// x returns the horizontal position of the center of the ith bucket
+func (a *chartArea) x(i int) float64 {
+	return a.left + (float64(i)+0.5)*a.width/float64(a.buckets)
+}
+
+// y returns the vertical position of the latency ms
+func (a *chartArea) y(ms int64) float64 {
+	return a.top + a.height*(1-float64(min(ms, a.ceiling))/float64(a.ceiling))
+}
+
+// niceCeiling returns the smallest of 1, 2 and 5 times a power of ten not below ms
+func niceCeiling(ms int64) int64 {
+	step := int64(1)
+	for {
+		for _, m := range []int64{1, 2, 5} {
+			if m*step >= ms {
+				return m * step
+			}
+		}
+		step *= 10
+	}
+}
+
+// segments returns the runs of consecutive buckets with measured latencies, as pairs of first and last index
+func segments(buckets []Bucket) [][2]int {
+	var runs [][2]int
+	for i := range buckets {
+		if buckets[i].latency == nil {
+			continue
+		}
+		if n := len(runs); n > 0 && runs[n-1][1] == i-1 {
+			runs[n-1][1] = i
+		} else {
+			runs = append(runs, [2]int{i, i})
+		}
+	}
+	return runs
+}
+
+// failed returns whether a check of the bucket failed
+func (b *Bucket) failed() bool {
+	return b.Status == testResult.NONE.String() || b.Status == testResult.PART.String()
+}
+
+// writeLine draws the median latencies of every run of buckets, as a dot for a run of a single bucket
+func writeLine(sb *strings.Builder, a *chartArea, buckets []Bucket, runs [][2]int) {
+	for _, run := range runs {
+		if run[0] == run[1] {
+			fmt.Fprintf(sb, `<circle class="latency-dot" cx="%.1f" cy="%.1f" r="1.5"/>`,
+				a.x(run[0]), a.y(buckets[run[0]].latency.P50))
+			continue
+		}
+		var points []string
+		for i := run[0]; i <= run[1]; i++ {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", a.x(i), a.y(buckets[i].latency.P50)))
+		}
+		fmt.Fprintf(sb, `<polyline class="latency-line" points="%s"/>`, strings.Join(points, " "))
+	}
+}
+
+// latencyCharts renders the latencies of the buckets of a port as an inline SVG sparkline of the median and
+// a detailed chart adding the band up to the 95th percentile and markers of the failed checks. Both are
+// empty if no latency was measured over the timeline.
+func latencyCharts(buckets []Bucket) (sparkline, chart template.HTML) {
+	runs := segments(buckets)
+	if len(runs) == 0 {
+		return "", ""
+	}
+	var peak, peakP50 int64
+	for i := range buckets {
+		if l := buckets[i].latency; l != nil {
+			peak = max(peak, l.P95)
+			peakP50 = max(peakP50, l.P50)
+		}
+	}
+
+	var sb strings.Builder
+	spark := chartArea{width: sparklineWidth, top: 2, height: sparklineHeight - 4, buckets: len(buckets), ceiling: max(peakP50, 1)}
+	fmt.Fprintf(&sb, `<svg class="latency-sparkline" viewBox="0 0 %d %d" width="%d" height="%d" role="img">`,
+		sparklineWidth, sparklineHeight, sparklineWidth, sparklineHeight)
+	fmt.Fprintf(&sb, `<title>Median response time, up to %d ms</title>`, peakP50)
+	writeLine(&sb, &spark, buckets, runs)
+	sb.WriteString(`</svg>`)
+	sparkline = template.HTML(sb.String())
+
+	sb.Reset()
+	a := chartArea{
+		left: chartLeft, top: chartTop, width: chartWidth - chartLeft, height: chartBottom - chartTop,
+		buckets: len(buckets), ceiling: niceCeiling(max(peak, 1)),
+	}
+	fmt.Fprintf(&sb, `<svg class="latency-chart" viewBox="0 0 %d %d" role="img">`, chartWidth, chartHeight)
+	sb.WriteString(`<title>Median response time, with the band up to the 95th percentile and the failed checks marked below</title>`)
+	for _, ms := range []int64{0, a.ceiling / 2, a.ceiling} {
+		fmt.Fprintf(&sb, `<line class="latency-grid" x1="%d" y1="%.1f" x2="%d" y2="%.1f"/>`, chartLeft, a.y(ms), chartWidth, a.y(ms))
+		fmt.Fprintf(&sb, `<text class="latency-label" x="%d" y="%.1f">%d ms</text>`, chartLeft-4, a.y(ms)+3, ms)
+	}
+	for _, run := range runs {
+		var upper, lower []string
+		for i := run[0]; i <= run[1]; i++ {
+			upper = append(upper, fmt.Sprintf("%.1f,%.1f", a.x(i), a.y(buckets[i].latency.P95)))
+			lower = append(lower, fmt.Sprintf("%.1f,%.1f", a.x(run[1]+run[0]-i), a.y(buckets[run[1]+run[0]-i].latency.P50)))
+		}
+		fmt.Fprintf(&sb, `<polygon class="latency-band" points="%s %s"/>`, strings.Join(upper, " "), strings.Join(lower, " "))
+	}
+	writeLine(&sb, &a, buckets, runs)
+
+	step := a.width / float64(len(buckets))
+	for i := range buckets {
+		b := &buckets[i]
+		if b.failed() {
+			fmt.Fprintf(&sb, `<rect class="latency-failure" x="%.1f" y="%d" width="%.1f" height="6"><title>%s: %s</title></rect>`,
+				a.left+float64(i)*step, chartBottom+4, math.Max(step-1, 1), template.HTMLEscapeString(b.Label), b.Status)
+		}
+		if l := b.latency; l != nil {
+			fmt.Fprintf(&sb, `<rect class="latency-hover" x="%.1f" y="%d" width="%.1f" height="%d"><title>%s: median %d ms, p95 %d ms</title></rect>`,
+				a.left+float64(i)*step, chartTop, step, chartBottom-chartTop, template.HTMLEscapeString(b.Label), l.P50, l.P95)
+		}
+	}
+	sb.WriteString(`</svg>`)
+	return sparkline, template.HTML(sb.String())
+}
@@ -0,0 +1,291 @@
+package report
+
+import (
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// maxFeedEntries is the number of the latest state changes and incidents listed in the feeds
+const maxFeedEntries = 50
+
+// feedEntry defines a state change of a service or an incident listed in the feeds. Its ID is derived from
+// what it reports, so that regenerating the feeds keeps the IDs of their entries.
+type feedEntry struct {
+	id        string
+	title     string
+	content   string // HTML
+	published time.Time
+	updated   time.Time
+}
+
+// atomFeed defines an Atom feed, as of RFC 4287. Its author is that of every entry, which have none.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Link      atomLink    `xml:"link"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// rssFeed defines an RSS 2.0 feed
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// feedPaths returns the paths of the Atom and RSS feeds of the report written to output, next to it
+func feedPaths(output string) (string, string) {
+	base := strings.TrimSuffix(output, filepath.Ext(output))
+	return base + ".atom", base + ".rss"
+}
+
+// feedNames returns the file names of the Atom and RSS feeds of the report written to output, for the report
+// to link to them
+func feedNames(output string) map[string]string {
+	atom, rss := feedPaths(output)
+	return map[string]string{"Atom": filepath.Base(atom), "RSS": filepath.Base(rss)}
+}
+
+// feedLink returns the link to the file name next to the report, absolute if the site URL is set
+func feedLink(t *config.ReportTarget, name string) string {
+	if t.SiteURL == "" {
+		return name
+	}
+	return strings.TrimSuffix(t.SiteURL, "/") + "/" + name
+}
+
+// stableID returns a URN identifying the parts, the same for the same parts on every run
+func stableID(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	// a name based UUID, as of RFC 4122
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// transitionTitle returns the title of the change of a service to a status
+func transitionTitle(name string, status testResult.TestResult) string {
+	switch status {
+	case testResult.ALL:
+		return name + " is operational"
+	case testResult.PART:
+		return name + " is degraded"
+	case testResult.NONE:
+		return name + " is down"
+	default:
+		return name + " is under maintenance"
+	}
+}
+
+// transitionEntries returns the changes of state found in the history of the services. Cancelled
+// and unknown checks do not change the state.
+func transitionEntries(results []ServiceResult) []feedEntry {
+	var entries []feedEntry
+	for _, svc := range results {
+		var last testResult.TestResult
+		for _, h := range svc.History {
+			status := testResult.ParseTestResult(h.Status)
+			if !status.IsValid() && status != testResult.MAINTENANCE {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, h.Time)
+			if err != nil {
+				continue
+			}
+			if last != "" && status != last {
+				from, _ := stateOf(last.String())
+				to, _ := stateOf(status.String())
+				entries = append(entries, feedEntry{
+					id:    stableID("transition", svc.Name, h.Time, status.String()),
+					title: transitionTitle(svc.Name, status),
+					content: fmt.Sprintf("<p>%s changed from %s to %s at %s.</p>", template.HTMLEscapeString(svc.Name),
+						from, to, t.Format(displayTimeFormat)),
+					published: t,
+					updated:   t,
+				})
+			}
+			last = status
+		}
+	}
+	return entries
+}
+
+// incidentEntries returns the incidents as entries, updated with their latest update
+func incidentEntries(infos []IncidentInfo) []feedEntry {
+	var entries []feedEntry
+	for _, info := range infos {
+		var sb strings.Builder
+		state := "Ongoing"
+		if !info.Ongoing {
+			state = "Resolved"
+		}
+		fmt.Fprintf(&sb, "<p>%s", state)
+		if info.Severity != "" {
+			fmt.Fprintf(&sb, ", %s", template.HTMLEscapeString(info.Severity))
+		}
+		fmt.Fprintf(&sb, ": %s", info.Start)
+		if info.End != "" {
+			fmt.Fprintf(&sb, " – %s", info.End)
+		}
+		if len(info.Services) > 0 {
+			fmt.Fprintf(&sb, "<br>Affects %s", template.HTMLEscapeString(strings.Join(info.Services, ", ")))
+		}
+		sb.WriteString("</p>")
+		if info.Description != "" {
+			fmt.Fprintf(&sb, "<p>%s</p>", template.HTMLEscapeString(info.Description))
+		}
+		if len(info.Updates) > 0 {
+			sb.WriteString("<ul>")
+			for _, u := range info.Updates {
+				fmt.Fprintf(&sb, "<li>%s", u.Time)
+				if u.Status != "" {
+					fmt.Fprintf(&sb, " %s", template.HTMLEscapeString(u.Status))
+				}
+				fmt.Fprintf(&sb, ": %s</li>", template.HTMLEscapeString(u.Message))
+			}
+			sb.WriteString("</ul>")
+		}
+		entries = append(entries, feedEntry{
+			id:        stableID("incident", info.ID),
+			title:     info.Title,
+			content:   sb.String(),
+			published: info.start,
+			updated:   info.updated,
+		})
+	}
+	return entries
+}
+
+// writeFeeds writes the Atom and RSS feeds of the latest state changes of the services and incidents
+// next to the report written by the target
+func writeFeeds(t *config.ReportTarget, results []ServiceResult, infos []IncidentInfo, latestTime string) error {
+	entries := append(transitionEntries(results), incidentEntries(infos)...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].updated.Equal(entries[j].updated) {
+			return entries[i].updated.After(entries[j].updated)
+		}
+		return entries[i].id < entries[j].id
+	})
+	if len(entries) > maxFeedEntries {
+		entries = entries[:maxFeedEntries]
+	}
+
+	// feeds with entries are as recent as their latest entry, so that they are written again unchanged
+	// until a service changes state or an incident is updated
+	updated, err := time.Parse(time.RFC3339, latestTime)
+	if err != nil {
+		updated = time.Now()
+	}
+	if len(entries) > 0 {
+		updated = entries[0].updated
+	}
+
+	title := "Service Status Report"
+	if t.Name != "" {
+		title = t.Name + " - " + title
+	}
+	atomPath, rssPath := feedPaths(t.Output)
+	page := feedLink(t, filepath.Base(t.Output))
+
+	atom := atomFeed{
+		Title:   title,
+		ID:      stableID("feed", t.Output),
+		Updated: updated.UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: title},
+		Links: []atomLink{
+			{Href: feedLink(t, filepath.Base(atomPath)), Rel: "self"},
+			{Href: page, Rel: "alternate"},
+		},
+	}
+	rss := rssFeed{Version: "2.0", Channel: rssChannel{
+		Title:         title,
+		Link:          page,
+		Description:   "Changes of the state of the services and incidents",
+		LastBuildDate: updated.UTC().Format(time.RFC1123Z),
+	}}
+	for _, e := range entries {
+		atom.Entries = append(atom.Entries, atomEntry{
+			Title:     e.title,
+			ID:        e.id,
+			Published: e.published.UTC().Format(time.RFC3339),
+			Updated:   e.updated.UTC().Format(time.RFC3339),
+			Link:      atomLink{Href: page, Rel: "alternate"},
+			Content:   atomContent{Type: "html", Body: e.content},
+		})
+		rss.Channel.Items = append(rss.Channel.Items, rssItem{
+			Title:       e.title,
+			Link:        page,
+			Description: e.content,
+			GUID:        rssGUID{Value: e.id},
+			PubDate:     e.updated.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	for path, feed := range map[string]interface{}{atomPath: atom, rssPath: rss} {
+		b, err := xml.MarshalIndent(feed, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode feed: %w", err)
+		}
+		if err := os.WriteFile(path, append([]byte(xml.Header), b...), 0644); err != nil {
+			return fmt.Errorf("failed to write feed: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+)
+
+func TestWriteFeedsAuthor(t *testing.T) {
+	start := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	infos := []IncidentInfo{{ID: "cdn-outage", Title: "CDN outage", Ongoing: true, start: start, updated: start}}
+
+	tests := []struct {
+		name       string
+		target     string
+		wantAuthor string
+	}{
+		{name: "named report", target: "Payments", wantAuthor: "Payments - Service Status Report"},
+		{name: "unnamed report", wantAuthor: "Service Status Report"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &config.ReportTarget{Name: tt.target, Output: filepath.Join(t.TempDir(), "index.html")}
+			if err := writeFeeds(target, nil, infos, start.Format(time.RFC3339)); err != nil {
+				t.Fatal(err)
+			}
+			atomPath, _ := feedPaths(target.Output)
+			b, err := os.ReadFile(atomPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// RFC 4287 requires an author on the feed when its entries have none
+			var feed struct {
+				Authors []struct {
+					Name string `xml:"name"`
+				} `xml:"author"`
+				Entries []struct {
+					Title   string     `xml:"title"`
+					Authors []struct{} `xml:"author"`
+				} `xml:"entry"`
+			}
+			if err := xml.Unmarshal(b, &feed); err != nil {
+				t.Fatal(err)
+			}
+			if len(feed.Authors) != 1 || feed.Authors[0].Name != tt.wantAuthor {
+				t.Errorf("feed authors = %+v, want one named %q", feed.Authors, tt.wantAuthor)
+			}
+			if len(feed.Entries) != 1 || feed.Entries[0].Title != "CDN outage" {
+				t.Fatalf("feed entries = %+v, want the incident", feed.Entries)
+			}
+			if len(feed.Entries[0].Authors) != 0 {
+				t.Errorf("entry has authors %+v, want them inherited from the feed", feed.Entries[0].Authors)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/pkg/incident"
+)
+
+// UpdateInfo defines an update of an incident shown in the report
+type UpdateInfo struct {
+	Time    string
+	Status  string
+	Message string
+
+	time time.Time
+}
+
+// IncidentInfo defines an incident shown in the report
+type IncidentInfo struct {
+	ID          string
+	Title       string
+	Description string
+	Severity    string
+	Services    []string
+	Start       string
+	End         string
+	Ongoing     bool
+	Updates     []UpdateInfo
+
+	// Detected is set on the incidents opened from consecutive failed checks, Ports then listing the failing ports
+	Detected bool
+	Ports    []string
+
+	start, updated time.Time // when the incident started and was last updated, ended or started
+	end            *time.Time
+}
+
+// newIncidentInfo returns the incident shown by the report target, with its texts redacted
+// and only the services in shown listed
+func newIncidentInfo(inc *incident.Incident, shown map[string]bool, t *config.ReportTarget) IncidentInfo {
+	redact := t.Redactor()
+	info := IncidentInfo{
+		ID:          inc.ID,
+		Title:       redact(inc.Title),
+		Description: redact(inc.Description),
+		Severity:    inc.Severity,
+		Start:       inc.Start.Format(displayTimeFormat),
+		Ongoing:     inc.Ongoing(),
+		Detected:    inc.Detected,
+		start:       inc.Start,
+		updated:     inc.Start,
+	}
+	if inc.End != nil {
+		info.End = inc.End.Format(displayTimeFormat)
+		info.end = inc.End
+		if inc.End.After(info.updated) {
+			info.updated = *inc.End
+		}
+	}
+	for _, svc := range inc.Services {
+		if name := redact(svc); shown[name] {
+			info.Services = append(info.Services, name)
+		}
+	}
+	for _, p := range inc.Ports {
+		name := p.Name
+		if name == "" {
+			// the identity of an unnamed port may contain the URL
+			if t.HideURLs {
+				continue
+			}
+			name = p.ID
+		}
+		if t.ShowDetails && p.Reason != "" {
+			name = fmt.Sprintf("%s: %s", name, p.Reason)
+		}
+		info.Ports = append(info.Ports, redact(name))
+	}
+	for _, u := range inc.Updates {
+		info.Updates = append(info.Updates, UpdateInfo{
+			Time:    u.Time.Format(displayTimeFormat),
+			Status:  redact(u.Status),
+			Message: redact(u.Message),
+			time:    u.Time,
+		})
+		if u.Time.After(info.updated) {
+			info.updated = u.Time
+		}
+	}
+	return info
+}
+
+// shownIncidents returns the incidents affecting the services shown by the report target that are
+// ongoing or ended after since. The names of the shown services are redacted by the target.
+func shownIncidents(incidents []incident.Incident, results []ServiceResult, since time.Time, t *config.ReportTarget) []IncidentInfo {
+	shown := map[string]bool{}
+	for _, r := range results {
+		shown[r.Name] = true
+	}
+
+	var infos []IncidentInfo
+	for i := range incidents {
+		inc := &incidents[i]
+		if !inc.Ongoing() && inc.End.Before(since) {
+			continue
+		}
+		info := newIncidentInfo(inc, shown, t)
+		if len(inc.Services) == 0 || len(info.Services) > 0 {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// markIncidents records on every service the titles of its ongoing incidents, its mean time to recovery
+// and between failures, and on every bucket of its timeline the title of the first incident it overlaps
+func markIncidents(results []ServiceResult, incidents []incident.Incident, now time.Time) {
+	for i := range results {
+		svc := &results[i]
+		for _, inc := range incidents {
+			if !inc.Affects(svc.Name) {
+				continue
+			}
+			if inc.Ongoing() {
+				svc.Incidents = append(svc.Incidents, inc.Title)
+			}
+			for j := range svc.Timeline {
+				b := &svc.Timeline[j]
+				if b.Incident == "" && inc.Start.Before(b.end) && (inc.Ongoing() || inc.End.After(b.start)) {
+					b.Incident = inc.Title
+				}
+			}
+		}
+
+		if len(svc.History) == 0 {
+			continue
+		}
+		observed, err := time.Parse(time.RFC3339, svc.History[0].Time)
+		if err != nil {
+			continue
+		}
+		if mttr, mtbf, n := incident.Reliability(incidents, svc.Name, observed, now); n > 0 {
+			svc.MTBF = formatDuration(mtbf)
+			if mttr > 0 {
+				svc.MTTR = formatDuration(mttr)
+			}
+		}
+	}
+}
+
+// formatDuration formats d with its two largest units, such as "3d 4h" or "25m"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days, hours, minutes := int(d/(24*time.Hour)), int(d/time.Hour)%24, int(d/time.Minute)%60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+// incidentRecord defines an incident in the incidents JSON file of a report target
+type incidentRecord struct {
+	ID          string         `json:"id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Services    []string       `json:"services,omitempty"`
+	Severity    string         `json:"severity"`
+	Start       time.Time      `json:"start"`
+	End         *time.Time     `json:"end,omitempty"`
+	Updates     []updateRecord `json:"updates,omitempty"`
+	Detected    bool           `json:"detected,omitempty"`
+	Ports       []string       `json:"ports,omitempty"`
+}
+
+type updateRecord struct {
+	Time    time.Time `json:"time"`
+	Status  string    `json:"status,omitempty"`
+	Message string    `json:"message"`
+}
+
+// incidentsPath returns the path of the incidents JSON file of the report written to output, next to it
+func incidentsPath(output string) string {
+	return strings.TrimSuffix(output, filepath.Ext(output)) + ".incidents.json"
+}
+
+// writeIncidents writes the incidents shown by the target as JSON next to the report it writes,
+// as redacted and filtered as in the report
+func writeIncidents(t *config.ReportTarget, infos []IncidentInfo) error {
+	records := []incidentRecord{}
+	for _, info := range infos {
+		r := incidentRecord{
+			ID:          info.ID,
+			Title:       info.Title,
+			Description: info.Description,
+			Services:    info.Services,
+			Severity:    info.Severity,
+			Start:       info.start,
+			End:         info.end,
+			Detected:    info.Detected,
+			Ports:       info.Ports,
+		}
+		for _, u := range info.Updates {
+			r.Updates = append(r.Updates, updateRecord{Time: u.time, Status: u.Status, Message: u.Message})
+		}
+		records = append(records, r)
+	}
+	b, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode incidents: %w", err)
+	}
+	if err := os.WriteFile(incidentsPath(t.Output), b, 0644); err != nil {
+		return fmt.Errorf("failed to write incidents file: %w", err)
+	}
+	return nil
+}
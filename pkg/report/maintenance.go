@@ -0,0 +1,80 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+)
+
+// upcomingMaintenanceDays is how far ahead maintenance windows are shown in the report
+const upcomingMaintenanceDays = 7
+
+// displayTimeFormat is the format of the times of maintenance windows and incidents in the report
+const displayTimeFormat = "2006-01-02 15:04 MST"
+
+// MaintenanceInfo defines a maintenance window in progress or upcoming shown in the report.
+// Service is empty for the windows of all services.
+type MaintenanceInfo struct {
+	Service string
+	Name    string
+	Start   string
+	End     string
+	Active  bool
+
+	start time.Time
+}
+
+// newMaintenanceInfo returns the occurrence of the window in progress at now or starting within
+// upcomingMaintenanceDays, or false if there is none
+func newMaintenanceInfo(service string, w *config.MaintenanceWindow, now time.Time) (MaintenanceInfo, bool) {
+	start, end, ok := w.Occurrence(now)
+	if !ok || start.After(now.AddDate(0, 0, upcomingMaintenanceDays)) {
+		return MaintenanceInfo{}, false
+	}
+	return MaintenanceInfo{
+		Service: service,
+		Name:    w.Name,
+		Start:   start.Format(displayTimeFormat),
+		End:     end.Format(displayTimeFormat),
+		Active:  !start.After(now),
+		start:   start,
+	}, true
+}
+
+// upcomingMaintenance returns the maintenance windows in progress or upcoming of the services shown
+// in the report, in start order
+func upcomingMaintenance(cfg *config.Config, results []ServiceResult, now time.Time, redact func(string) string) []MaintenanceInfo {
+	shown := map[string]bool{}
+	for _, r := range results {
+		if !r.Retired {
+			shown[r.Name] = true
+		}
+	}
+	if len(shown) == 0 {
+		return nil
+	}
+
+	var infos []MaintenanceInfo
+	for i := range cfg.Maintenance {
+		if info, ok := newMaintenanceInfo("", &cfg.Maintenance[i], now); ok {
+			info.Name = redact(info.Name)
+			infos = append(infos, info)
+		}
+	}
+	for _, svc := range cfg.Services {
+		// the names of the results are redacted for the report target
+		name := redact(svc.Name)
+		if !shown[name] {
+			continue
+		}
+		for i := range svc.Maintenance {
+			if info, ok := newMaintenanceInfo(name, &svc.Maintenance[i], now); ok {
+				info.Name = redact(info.Name)
+				infos = append(infos, info)
+			}
+		}
+	}
+	sort.SliceStable(infos, func(i, j int) bool { return infos[i].start.Before(infos[j].start) })
+	return infos
+}
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// orderedKeys returns the keys of m present in order, in that order, followed by the other keys in name order
+func orderedKeys[V any](m map[string]V, order []string) []string {
+	var keys, rest []string
+	for _, k := range order {
+		if _, ok := m[k]; ok && !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range m {
+		if !slices.Contains(keys, k) {
+			rest = append(rest, k)
+		}
+	}
+	slices.Sort(rest)
+	return append(keys, rest...)
+}
+
+// statusRank orders the statuses from the worst to the best
+func statusRank(status string) int {
+	switch testResult.ParseTestResult(status) {
+	case testResult.NONE:
+		return 0
+	case testResult.PART:
+		return 1
+	case testResult.ALL:
+		return 3
+	default:
+		// cancelled and unknown checks say nothing about the service
+		return 2
+	}
+}
+
+// lastStatus returns the status of the latest check of the service
+func (r *ServiceResult) lastStatus() string {
+	if len(r.History) == 0 {
+		return testResult.UNKNOWN.String()
+	}
+	return r.History[len(r.History)-1].Status
+}
+
+// sortResults orders the services by mode, keeping their current order for ties
+func sortResults(results []ServiceResult, mode string) {
+	switch mode {
+	case config.SortName:
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Name < results[j].Name
+		})
+	case config.SortStatus:
+		sort.SliceStable(results, func(i, j int) bool {
+			return statusRank(results[i].lastStatus()) < statusRank(results[j].lastStatus())
+		})
+	case config.SortAvailability:
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Availability < results[j].Availability
+		})
+	}
+}
@@ -0,0 +1,346 @@
+// Package report renders the HTML status page from the service history.
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/pkg/history"
+	"github.com/wcy-dt/ponghub/pkg/incident"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// ServiceHistory defines a status of a service shown in the report
+type ServiceHistory struct {
+	Status string
+	Time   string
+}
+
+// PortHistory defines a status of a port shown in the report, with the details of the check if shown
+type PortHistory struct {
+	ID         string
+	Time       string
+	Status     string
+	StatusCode int
+	LatencyMs  int64
+	Error      string
+}
+
+// PortResult defines a port of a service shown in the report, with the identity its history is kept under.
+// URL holds what the port checks, and is empty if it is hidden. Sparkline and LatencyChart hold the inline
+// SVG charts of its latency over the timeline, empty if none was measured.
+type PortResult struct {
+	ID           string
+	Name         string
+	URL          string
+	Description  string
+	History      []PortHistory
+	Latest       PortHistory
+	Timeline     []Bucket
+	Windows      []WindowAvailability
+	Sparkline    template.HTML
+	LatencyChart template.HTML
+
+	named bool // whether Name was set in the configuration rather than derived from the URL
+}
+
+// newPortResult returns the port shown in the report for a configured port, with its secrets redacted
+func newPortResult(p *config.PortConfig, redact func(string) string) PortResult {
+	port := PortResult{
+		ID:          redact(p.Identity()),
+		Name:        redact(p.DisplayName()),
+		Description: p.Description,
+		named:       p.Name != "",
+	}
+	if !p.HideURL {
+		port.URL = redact(p.Endpoint())
+	}
+	return port
+}
+
+// ServiceResult defines a service shown in the report
+type ServiceResult struct {
+	Name         string
+	Group        string
+	History      []ServiceHistory
+	Timeline     []Bucket
+	Axis         []string // dates labelling the timeline
+	Ports        []PortResult
+	Availability float64
+	Windows      []WindowAvailability
+	SLO          *SLOResult // service level objective, nil if the service has none
+	Retired      bool       // whether the service was removed from the configuration
+	RetiredTime  string     // time the service was found missing from the configuration, if known
+	Incidents    []string   // titles of the ongoing incidents of the service
+	MTTR         string     // mean time to recovery from the detected incidents, empty if none ended
+	MTBF         string     // mean time between the detected incidents, empty if there are none
+}
+
+// GroupResult defines a group of services shown in the report. Services without a group
+// are collected in a group with an empty name, shown without a group header, and retired
+// services in an archived group.
+type GroupResult struct {
+	Name     string
+	Status   string
+	Services []ServiceResult
+	Archived bool
+}
+
+// archiveGroupName is the name of the group of the retired services
+const archiveGroupName = "Archived"
+
+// groupResults collects the services by group, keeping the order of the services, with the groups
+// in the order of their first service, then the ungrouped services and the retired services last
+func groupResults(results []ServiceResult) []GroupResult {
+	var groups []GroupResult
+	archive := GroupResult{Name: archiveGroupName, Status: testResult.UNKNOWN.String(), Archived: true}
+	index := map[string]int{}
+	for _, r := range results {
+		if r.Retired {
+			archive.Services = append(archive.Services, r)
+			continue
+		}
+		i, ok := index[r.Group]
+		if !ok {
+			i = len(groups)
+			index[r.Group] = i
+			groups = append(groups, GroupResult{Name: r.Group})
+		}
+		groups[i].Services = append(groups[i].Services, r)
+	}
+
+	for i := range groups {
+		// the status of a group is the merged latest known status of its services
+		var latest []testResult.TestResult
+		for _, svc := range groups[i].Services {
+			if status := testResult.ParseTestResult(svc.lastStatus()); status.IsValid() {
+				latest = append(latest, status)
+			}
+		}
+		groups[i].Status = testResult.UNKNOWN.String()
+		if len(latest) > 0 {
+			groups[i].Status = history.MergeOnlineStatus(latest).String()
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Name != "" && groups[j].Name == ""
+	})
+	if len(archive.Services) > 0 {
+		groups = append(groups, archive)
+	}
+	return groups
+}
+
+// buildService converts the log data of a service into the service shown in the report, with the
+// configured ports in their order followed by the ports no longer configured
+func buildService(name string, svcData *history.ServiceLog, configured []PortResult, now time.Time, r *config.ReportConfig) ServiceResult {
+	tl := newTimeline(&r.Timeline, now)
+	serviceHistory := []ServiceHistory{}
+	for _, entry := range svcData.ServiceHistory {
+		serviceHistory = append(serviceHistory, ServiceHistory{Status: entry.Online.String(), Time: entry.Time})
+	}
+
+	var ports []PortResult
+	var ids []string
+	byID := map[string]PortResult{}
+	for _, p := range configured {
+		ids = append(ids, p.ID)
+		byID[p.ID] = p
+	}
+	portSeries := svcData.PortSeries()
+	for _, id := range orderedKeys(svcData.Ports, ids) {
+		port, ok := byID[id]
+		if !ok {
+			port = PortResult{ID: id, Name: id, URL: id}
+		}
+		for _, entry := range svcData.Ports[id] {
+			port.History = append(port.History, PortHistory{
+				ID:         id,
+				Time:       entry.Time,
+				Status:     entry.Online.String(),
+				StatusCode: entry.StatusCode,
+				LatencyMs:  entry.LatencyMs,
+				Error:      entry.Error,
+			})
+		}
+		if len(port.History) > 0 {
+			port.Latest = port.History[len(port.History)-1]
+		}
+		port.Timeline = tl.buckets(portSeries[id], r)
+		port.Sparkline, port.LatencyChart = latencyCharts(port.Timeline)
+		port.Windows = windowAvailability(portSeries[id], now, r)
+		ports = append(ports, port)
+	}
+
+	return ServiceResult{
+		Name:         name,
+		History:      serviceHistory,
+		Timeline:     tl.buckets(svcData.ServiceSeries(), r),
+		Axis:         tl.axis(),
+		Ports:        ports,
+		Availability: overallAvailability(svcData.ServiceSeries(), now, r),
+		Windows:      windowAvailability(svcData.ServiceSeries(), now, r),
+	}
+}
+
+// latestTime returns the time of the latest entry in the log data
+func latestTime(logData history.Log) string {
+	var latest string
+	for _, svcData := range logData {
+		for _, entry := range svcData.ServiceHistory {
+			latest = max(latest, entry.Time)
+		}
+		for _, historyArr := range svcData.Ports {
+			for _, entry := range historyArr {
+				latest = max(latest, entry.Time)
+			}
+		}
+	}
+	return latest
+}
+
+// buildResults converts the log data into the services shown by the report target, in the order set in the
+// configuration and with the groups taken from it, marks their incidents, and returns the latest update time
+func buildResults(cfg *config.Config, logData history.Log, incidents []incident.Incident, t *config.ReportTarget) ([]ServiceResult, string, error) {
+	selector, err := t.Selector()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var names []string
+	svcOf := map[string]*config.ServiceConfig{}
+	portsOf := map[string][]PortResult{}
+	for i := range cfg.Services {
+		svc := &cfg.Services[i]
+		names = append(names, svc.Name)
+		svcOf[svc.Name] = svc
+		portsOf[svc.Name] = []PortResult{}
+		for _, p := range slices.Concat(svc.Health, svc.API) {
+			// the log holds the identities with their secrets redacted
+			portsOf[svc.Name] = append(portsOf[svc.Name], newPortResult(&p, cfg.Redact))
+		}
+	}
+
+	now := time.Now()
+	var results []ServiceResult
+	for _, svcName := range orderedKeys(logData, names) {
+		svcData := logData[svcName]
+		if len(svcData.ServiceHistory) == 0 {
+			// only the rollups of a service no longer checked are left
+			continue
+		}
+		svc, configured := svcOf[svcName]
+		if !configured {
+			if t.Retired == config.RetiredHide {
+				continue
+			}
+			svc = &config.ServiceConfig{Name: svcName}
+		}
+		if !selector.Matches(svc) {
+			continue
+		}
+		result := buildService(svcName, svcData, portsOf[svcName], now, &t.ReportConfig)
+		if svc.SLO != nil {
+			result.SLO = newSLOResult(svc.SLO, svcData.ServiceSeries(), now, &t.ReportConfig)
+		}
+		result.Group = svc.Group
+		result.Retired = !configured
+		result.RetiredTime = svcData.Retired
+		results = append(results, result)
+	}
+	sortResults(results, t.Sort)
+	markIncidents(results, incidents, now)
+	restrict(results, t)
+	return results, latestTime(logData), nil
+}
+
+// Generate renders the report target for the log data and the incidents and writes it to w
+func Generate(cfg *config.Config, logData history.Log, incidents []incident.Incident, t *config.ReportTarget, w io.Writer) error {
+	results, latestTime, err := buildResults(cfg, logData, incidents, t)
+	if err != nil {
+		return err
+	}
+	return render(cfg, results, targetIncidents(cfg, incidents, results, t), latestTime, t, w)
+}
+
+// targetIncidents returns the incidents shown by the report target, as long as the history they affect is kept
+func targetIncidents(cfg *config.Config, incidents []incident.Incident, results []ServiceResult, t *config.ReportTarget) []IncidentInfo {
+	return shownIncidents(incidents, results, time.Now().AddDate(0, 0, -cfg.MaxLogDays), t)
+}
+
+// render renders the services and incidents of the report target with its template and writes them to w
+func render(cfg *config.Config, results []ServiceResult, incidentInfos []IncidentInfo, latestTime string, t *config.ReportTarget, w io.Writer) error {
+	funcMap := template.FuncMap{
+		"sub": func(a, b int) int { return a - b },
+		"until": func(n int) []int {
+			arr := make([]int, n)
+			for i := range n {
+				arr[i] = i
+			}
+			return arr
+		},
+		"mul": func(a, b float64) float64 { return a * b },
+	}
+	tmpl, err := template.New(filepath.Base(t.Template)).Funcs(funcMap).ParseFiles(t.Template)
+	if err != nil {
+		return fmt.Errorf("failed to parse report template: %w", err)
+	}
+	return tmpl.Execute(w, map[string]interface{}{
+		"Title":       t.Name,
+		"Results":     results,
+		"Groups":      groupResults(results),
+		"Maintenance": upcomingMaintenance(cfg, results, time.Now(), t.Redactor()),
+		"Incidents":   incidentInfos,
+		"UpdateTime":  latestTime,
+		"Feeds":       feedNames(t.Output),
+	})
+}
+
+// generateTarget renders the report target for the log data and the incidents and writes it to its output file,
+// with its feeds and the badges of its services and groups next to it
+func generateTarget(cfg *config.Config, logData history.Log, incidents []incident.Incident, t *config.ReportTarget) error {
+	results, latestTime, err := buildResults(cfg, logData, incidents, t)
+	if err != nil {
+		return err
+	}
+	incidentInfos := targetIncidents(cfg, incidents, results, t)
+	f, err := os.Create(t.Output)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	if err := render(cfg, results, incidentInfos, latestTime, t, f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := writeFeeds(t, results, incidentInfos, latestTime); err != nil {
+		return err
+	}
+	if err := writeIncidents(t, incidentInfos); err != nil {
+		return err
+	}
+	return writeBadges(t.Output, results)
+}
+
+// GenerateReport generates every report target from the log data at logPath and the incidents
+func GenerateReport(cfg *config.Config, logPath string, incidents []incident.Incident, targets []config.ReportTarget) error {
+	logData, err := history.Load(logPath)
+	if err != nil {
+		return err
+	}
+	for i := range targets {
+		if err := generateTarget(cfg, logData, incidents, &targets[i]); err != nil {
+			return fmt.Errorf("report %s: %w", targets[i].Output, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+)
+
+// restrict removes from the services what the report target does not show, and redacts the rest
+func restrict(results []ServiceResult, t *config.ReportTarget) {
+	redact := t.Redactor()
+	for i := range results {
+		svc := &results[i]
+		svc.Name = redact(svc.Name)
+		svc.Group = redact(svc.Group)
+		for j := range svc.Incidents {
+			svc.Incidents[j] = redact(svc.Incidents[j])
+		}
+		for j := range svc.Timeline {
+			svc.Timeline[j].Incident = redact(svc.Timeline[j].Incident)
+		}
+		if t.HidePorts {
+			svc.Ports = nil
+			continue
+		}
+		for j := range svc.Ports {
+			restrictPort(&svc.Ports[j], j, t, redact)
+		}
+	}
+}
+
+// restrictPort removes from the jth port of a service what the report target does not show, and redacts the rest
+func restrictPort(port *PortResult, j int, t *config.ReportTarget, redact func(string) string) {
+	if t.HideURLs {
+		// the identity and a derived name may contain the URL
+		port.ID, port.URL = "", ""
+		if !port.named {
+			port.Name = fmt.Sprintf("Port %d", j+1)
+		}
+	}
+	port.ID = redact(port.ID)
+	port.Name = redact(port.Name)
+	port.URL = redact(port.URL)
+	port.Description = redact(port.Description)
+
+	for k := range port.History {
+		restrictHistory(&port.History[k], port.ID, t, redact)
+	}
+	restrictHistory(&port.Latest, port.ID, t, redact)
+	if t.HideLatency {
+		port.Sparkline, port.LatencyChart = "", ""
+	}
+	for k := range port.Timeline {
+		b := &port.Timeline[k]
+		if !t.ShowDetails {
+			b.StatusCode, b.Error = 0, ""
+		}
+		b.Error = redact(b.Error)
+	}
+}
+
+// restrictHistory removes from a status of a port the details the report target does not show, and redacts the rest
+func restrictHistory(h *PortHistory, id string, t *config.ReportTarget, redact func(string) string) {
+	h.ID = id
+	if !t.ShowDetails {
+		h.StatusCode, h.LatencyMs, h.Error = 0, 0, ""
+	}
+	h.Error = redact(h.Error)
+}
@@ -0,0 +1,161 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wcy-dt/ponghub/pkg/config"
+	"github.com/wcy-dt/ponghub/pkg/history"
+	"github.com/wcy-dt/ponghub/protos/testResult"
+)
+
+// axisLabels is the number of dates labelling the axis of the timeline
+const axisLabels = 5
+
+// Bucket defines a period of the timeline of a service or port: the worst status checked in it, the share
+// of available checks as a percentage if the timeline is colored by percentage, the title of the incident
+// it overlaps if any, and for ports the details of the latest failed check
+type Bucket struct {
+	Label      string
+	Status     string
+	Percent    string
+	Incident   string
+	StatusCode int
+	Error      string
+
+	start, end time.Time
+	latency    *history.Latency // the latencies of the checks of the bucket, nil if none were measured
+}
+
+// worstRank orders the statuses from the worst to the least informative
+func worstRank(status testResult.TestResult) int {
+	switch status {
+	case testResult.NONE:
+		return 0
+	case testResult.PART:
+		return 1
+	case testResult.ALL:
+		return 2
+	case testResult.MAINTENANCE:
+		return 3
+	case testResult.CANCELLED:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// timeline defines the buckets of the status bars, the last one holding now
+type timeline struct {
+	config.Timeline
+	start time.Time
+}
+
+// newTimeline returns the timeline of the settings ending with the bucket holding now
+func newTimeline(t *config.Timeline, now time.Time) timeline {
+	bucket := time.Duration(t.Bucket)
+	end := now.UTC().Truncate(bucket).Add(bucket)
+	return timeline{Timeline: *t, start: end.Add(-time.Duration(t.Span))}
+}
+
+// index returns the bucket the time t falls in, or false if it is outside the timeline
+func (tl *timeline) index(t time.Time) (int, bool) {
+	if t.Before(tl.start) {
+		return 0, false
+	}
+	i := int(t.Sub(tl.start) / time.Duration(tl.Bucket))
+	return i, i < tl.Buckets()
+}
+
+// labelLayout returns the layout of the times of the buckets and axis
+func (tl *timeline) labelLayout() string {
+	switch {
+	case time.Duration(tl.Bucket) >= 24*time.Hour:
+		return "Jan 2"
+	case time.Duration(tl.Span) > 24*time.Hour:
+		return "Jan 2 15:04"
+	default:
+		return "15:04"
+	}
+}
+
+// axis returns the dates labelling the axis of the timeline, evenly spread from its start to its end
+func (tl *timeline) axis() []string {
+	var labels []string
+	n := tl.Buckets()
+	for i := range axisLabels {
+		t := tl.start.Add(time.Duration(i*(n-1)/(axisLabels-1)) * time.Duration(tl.Bucket))
+		labels = append(labels, t.Format(tl.labelLayout()))
+	}
+	return labels
+}
+
+// buckets aggregates the series into the buckets of the timeline, reading the rollups starting in every bucket
+// and its checks
+func (tl *timeline) buckets(s history.Series, r *config.ReportConfig) []Bucket {
+	n := tl.Buckets()
+	buckets := make([]Bucket, n)
+	worst := make([]testResult.TestResult, n)
+	up := make([]float64, n)
+	scored := make([]int, n)
+	latencies := make([][]int64, n)
+	add := func(i int, status testResult.TestResult, count int) {
+		if worst[i] == "" || worstRank(status) < worstRank(worst[i]) {
+			worst[i] = status
+		}
+		if v, ok := score(status, r.PartCountsAs); ok {
+			up[i] += v * float64(count)
+			scored[i] += count
+		}
+	}
+
+	for _, rollup := range append(s.Daily, s.Hourly...) {
+		t, err := time.Parse(time.RFC3339, rollup.Time)
+		if err != nil {
+			continue
+		}
+		if i, ok := tl.index(t); ok {
+			for status, count := range rollup.Counts {
+				add(i, status, count)
+			}
+			buckets[i].latency = buckets[i].latency.Merge(rollup.Latency)
+		}
+	}
+	for _, e := range s.Entries {
+		t, err := time.Parse(time.RFC3339, e.Time)
+		if err != nil {
+			continue
+		}
+		i, ok := tl.index(t)
+		if !ok {
+			continue
+		}
+		add(i, e.Online, 1)
+		if e.LatencyMs > 0 {
+			latencies[i] = append(latencies[i], e.LatencyMs)
+		}
+		if e.Online != testResult.ALL && (e.StatusCode != 0 || e.Error != "") {
+			buckets[i].StatusCode, buckets[i].Error = e.StatusCode, e.Error
+		}
+	}
+
+	bucket := time.Duration(tl.Bucket)
+	for i := range buckets {
+		b := &buckets[i]
+		b.start = tl.start.Add(time.Duration(i) * bucket)
+		b.end = b.start.Add(bucket)
+		b.latency = b.latency.Merge(history.NewLatency(latencies[i]))
+		b.Label = b.start.Format(tl.labelLayout())
+		if bucket < 24*time.Hour {
+			b.Label += " – " + b.end.Format("15:04")
+		}
+		b.Status = testResult.UNKNOWN.String()
+		if worst[i] != "" {
+			b.Status = worst[i].String()
+		}
+		if tl.Color == config.ColorPercentage && scored[i] > 0 {
+			b.Percent = fmt.Sprintf("%.0f%%", 100*up[i]/float64(scored[i]))
+		}
+	}
+	return buckets
+}
@@ -1,5 +1,7 @@
 package defaultConfig
 
+import "time"
+
 const (
 	// timeout is the default timeout for service checks in seconds
 	timeout = 5
@@ -9,6 +11,18 @@ const (
 
 	// maxLogDays is the default maximum number of days to keep logs
 	maxLogDays = 30
+
+	// incidentThreshold is the default number of consecutive failed checks that open an incident
+	incidentThreshold = 3
+
+	// sloWindowDays is the default number of days over which a service level objective is measured
+	sloWindowDays = 30
+
+	// hourlyDays is the default number of days to keep hourly rollups of the logs
+	hourlyDays = 90
+
+	// dailyDays is the default number of days to keep daily rollups of the logs
+	dailyDays = 365
 )
 
 // GetDefaultTimeout returns the default timeout for service checks
@@ -26,6 +40,26 @@ func GetDefaultMaxLogDays() int {
 	return maxLogDays
 }
 
+// GetDefaultIncidentThreshold returns the default number of consecutive failed checks that open an incident
+func GetDefaultIncidentThreshold() int {
+	return incidentThreshold
+}
+
+// GetDefaultSLOWindowDays returns the default number of days over which a service level objective is measured
+func GetDefaultSLOWindowDays() int {
+	return sloWindowDays
+}
+
+// GetDefaultHourlyDays returns the default number of days to keep hourly rollups of the logs
+func GetDefaultHourlyDays() int {
+	return hourlyDays
+}
+
+// GetDefaultDailyDays returns the default number of days to keep daily rollups of the logs
+func GetDefaultDailyDays() int {
+	return dailyDays
+}
+
 // SetDefaultTimeout sets the default timeout for a given configuration pointer
 func SetDefaultTimeout(cfg *int) {
 	if cfg == nil || *cfg <= 0 {
@@ -47,6 +81,89 @@ func SetDefaultMaxLogDays(cfg *int) {
 	}
 }
 
+// SetDefaultIncidentThreshold sets the default incident threshold for a given configuration pointer
+func SetDefaultIncidentThreshold(cfg *int) {
+	if cfg == nil || *cfg <= 0 {
+		*cfg = GetDefaultIncidentThreshold()
+	}
+}
+
+// SetDefaultSLOWindowDays sets the default service level objective window for a given configuration pointer
+func SetDefaultSLOWindowDays(cfg *int) {
+	if cfg == nil || *cfg <= 0 {
+		*cfg = GetDefaultSLOWindowDays()
+	}
+}
+
+const (
+	// retryInitialInterval is the default delay before the first retry
+	retryInitialInterval = time.Second
+
+	// retryMaxInterval is the default upper bound of the delay between retries
+	retryMaxInterval = 30 * time.Second
+
+	// retryMultiplier is the default factor applied to the delay after each retry
+	retryMultiplier = 2.0
+
+	// retryJitter is the default fraction of the delay randomly added or removed
+	retryJitter = 0.2
+)
+
+const (
+	// unknownAfter is the default time a checked state is assumed to last when no check follows it
+	unknownAfter = time.Hour
+
+	// timelineSpan is the default period shown by the status bars of the report,
+	// 72 buckets of timelineBucket as often as the scheduled runs
+	timelineSpan = 36 * time.Hour
+
+	// timelineBucket is the default period of every bucket of the status bars of the report
+	timelineBucket = 30 * time.Minute
+)
+
+// GetDefaultUnknownAfter returns the default time a checked state is assumed to last when no check follows it
+func GetDefaultUnknownAfter() time.Duration {
+	return unknownAfter
+}
+
+// GetDefaultTimelineSpan returns the default period shown by the status bars of the report
+func GetDefaultTimelineSpan() time.Duration {
+	return timelineSpan
+}
+
+// GetDefaultTimelineBucket returns the default period of every bucket of the status bars of the report
+func GetDefaultTimelineBucket() time.Duration {
+	return timelineBucket
+}
+
+// retryOn is the default list of failure reasons that are retried
+var retryOn = []string{"connection", "timeout", "5xx", "plugin"}
+
+// GetDefaultRetryInitialInterval returns the default delay before the first retry
+func GetDefaultRetryInitialInterval() time.Duration {
+	return retryInitialInterval
+}
+
+// GetDefaultRetryMaxInterval returns the default upper bound of the delay between retries
+func GetDefaultRetryMaxInterval() time.Duration {
+	return retryMaxInterval
+}
+
+// GetDefaultRetryMultiplier returns the default factor applied to the delay after each retry
+func GetDefaultRetryMultiplier() float64 {
+	return retryMultiplier
+}
+
+// GetDefaultRetryJitter returns the default fraction of the delay randomly added or removed
+func GetDefaultRetryJitter() float64 {
+	return retryJitter
+}
+
+// GetDefaultRetryOn returns the default list of failure reasons that are retried
+func GetDefaultRetryOn() []string {
+	return append([]string(nil), retryOn...)
+}
+
 const (
 	// configPath is the default path to the configuration file
 	configPath = "config.yaml"
@@ -56,6 +173,15 @@ const (
 
 	// reportPath is the default path to the HTML report file
 	reportPath = "data/index.html"
+
+	// templatePath is the default path to the HTML report template
+	templatePath = "templates/report.html"
+
+	// incidentsDir is the default directory of the incident files, relative to the configuration file
+	incidentsDir = "incidents"
+
+	// incidentLogPath is the default path to the data file where detected incidents are stored
+	incidentLogPath = "data/ponghub_incidents.json"
 )
 
 // GetConfigPath returns the default path to the configuration file
@@ -72,3 +198,18 @@ func GetLogPath() string {
 func GetReportPath() string {
 	return reportPath
 }
+
+// GetTemplatePath returns the default path to the HTML report template
+func GetTemplatePath() string {
+	return templatePath
+}
+
+// GetIncidentsDir returns the default directory of the incident files, relative to the configuration file
+func GetIncidentsDir() string {
+	return incidentsDir
+}
+
+// GetIncidentLogPath returns the default path to the data file where detected incidents are stored
+func GetIncidentLogPath() string {
+	return incidentLogPath
+}
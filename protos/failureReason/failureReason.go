@@ -0,0 +1,53 @@
+package failureReason
+
+type FailureReason string
+
+const (
+	// CONNECTION represents a failure to connect or to read the response
+	CONNECTION FailureReason = "connection"
+
+	// TIMEOUT represents an attempt that did not finish within the timeout
+	TIMEOUT FailureReason = "timeout"
+
+	// SERVER_ERROR represents an unexpected 5xx response
+	SERVER_ERROR FailureReason = "5xx"
+
+	// CLIENT_ERROR represents an unexpected 4xx response
+	CLIENT_ERROR FailureReason = "4xx"
+
+	// MISMATCH represents a response whose status code or body does not match the expectation
+	MISMATCH FailureReason = "mismatch"
+
+	// PLUGIN represents a plugin command that exited with a non-OK code
+	PLUGIN FailureReason = "plugin"
+
+	// ERROR represents an attempt that could not be made, such as an invalid request
+	ERROR FailureReason = "error"
+
+	// UNKNOWN represents an unknown failure reason
+	UNKNOWN FailureReason = "unknown"
+)
+
+// String returns the string representation of the FailureReason
+func (fr FailureReason) String() string {
+	switch fr {
+	case CONNECTION, TIMEOUT, SERVER_ERROR, CLIENT_ERROR, MISMATCH, PLUGIN, ERROR:
+		return string(fr)
+	default:
+		return "unknown"
+	}
+}
+
+// IsValid checks if the FailureReason is valid
+func (fr FailureReason) IsValid() bool {
+	return fr.String() != "unknown"
+}
+
+// ParseFailureReason parses a string into a FailureReason
+func ParseFailureReason(s string) FailureReason {
+	fr := FailureReason(s)
+	if !fr.IsValid() {
+		return UNKNOWN
+	}
+	return fr
+}
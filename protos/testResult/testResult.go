@@ -12,6 +12,12 @@ const (
 	// NONE represents no ports are online
 	NONE TestResult = "none"
 
+	// CANCELLED represents a check interrupted by a deadline or signal before it finished
+	CANCELLED TestResult = "cancelled"
+
+	// MAINTENANCE represents a check during a maintenance window of the service
+	MAINTENANCE TestResult = "maintenance"
+
 	// UNKNOWN represents an unknown test result
 	UNKNOWN TestResult = "unknown"
 )
@@ -25,6 +31,10 @@ func (tr TestResult) String() string {
 		return "part"
 	case NONE:
 		return "none"
+	case CANCELLED:
+		return "cancelled"
+	case MAINTENANCE:
+		return "maintenance"
 	default:
 		return "unknown"
 	}
@@ -44,6 +54,10 @@ func ParseTestResult(s string) TestResult {
 		return PART
 	case "none":
 		return NONE
+	case "cancelled":
+		return CANCELLED
+	case "maintenance":
+		return MAINTENANCE
 	default:
 		return UNKNOWN
 	}